@@ -0,0 +1,217 @@
+// Package auth handles OAuth2 authorization with the Microsoft identity
+// platform and the named account profiles shared by the onenote tools.
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bnixon67/onenote"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultTenant allows both work/school and personal accounts
+	DefaultTenant = "common"
+
+	// DefaultTokenFile is the token store of the legacy default profile
+	DefaultTokenFile = "token.txt"
+)
+
+// DefaultScopes are requested when a profile does not list any
+var DefaultScopes = []string{"Notes.Read"}
+
+// Profile describes one account and where its token is stored
+type Profile struct {
+	// Name of the profile, set from the key in the profiles file
+	Name string `json:"-"`
+
+	// Tenant is the directory tenant, such as "common", "consumers",
+	// "organizations", a tenant id or a domain name
+	Tenant string `json:"tenant,omitempty"`
+
+	// ClientID and ClientSecret of the registered application,
+	// MSCLIENTID and MSCLIENTSECRET are used if empty
+	ClientID     string `json:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+
+	// Scopes requested during authorization
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Owner scope of the notebooks, such as "me", "users/{id}",
+	// "groups/{id}" or "sites/{id}"
+	Owner string `json:"owner,omitempty"`
+
+	// TokenStore is the file holding the access token, relative paths
+	// are relative to the directory of the profiles file
+	TokenStore string `json:"tokenStore,omitempty"`
+
+	// Cloud is the name of the Microsoft cloud, such as "global",
+	// "usgov-l4", "usgov-l5" or "china", global if empty
+	Cloud string `json:"cloud,omitempty"`
+
+	// Version of the Graph API, "v1.0" or "beta", v1.0 if empty
+	Version string `json:"version,omitempty"`
+}
+
+// Environment returns the Microsoft cloud of the profile
+func (p *Profile) Environment() onenote.Environment {
+	env, err := onenote.EnvironmentByName(p.Cloud)
+	if err != nil {
+		// unknown names are rejected when loading profiles
+		return onenote.Global
+	}
+	return env
+}
+
+// Configure applies the owner, cloud and version of the profile to c,
+// and the profile name as the account of the Cache
+func (p *Profile) Configure(c *onenote.Client) {
+	if p.Owner != "" {
+		c.Owner = p.Owner
+	}
+	if c.Account == "" {
+		c.Account = p.Name
+	}
+	c.Environment = p.Environment()
+	c.Version = p.Version
+}
+
+// ExpiryWarning is how long before the stored token expires NewClient
+// logs a warning
+const ExpiryWarning = 5 * time.Minute
+
+// NewClient returns a OneNote client using the stored access token
+// and the settings of the profile, and logs a warning if the token
+// expires within ExpiryWarning
+func (p *Profile) NewClient() (*onenote.Client, error) {
+	token, err := p.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// only a JWT has an expiry, examples/token.go shows the claims
+	claims, err := onenote.ParseClaims(token)
+	if err == nil && claims.ExpiresWithin(time.Now(), ExpiryWarning) {
+		log.Printf("token of profile %q expires at %v", p.Name, claims.Expiry())
+	}
+
+	c := onenote.NewClient(token)
+	p.Configure(c)
+
+	return c, nil
+}
+
+// TenantOrDefault returns the tenant of the profile or DefaultTenant
+func (p *Profile) TenantOrDefault() string {
+	if p.Tenant == "" {
+		return DefaultTenant
+	}
+	return p.Tenant
+}
+
+// Endpoint returns the OAuth2 endpoint for the tenant of the profile
+func (p *Profile) Endpoint() oauth2.Endpoint {
+	base := p.Environment().LoginURL + "/" + p.TenantOrDefault() + "/oauth2/v2.0"
+	return oauth2.Endpoint{
+		AuthURL:  base + "/authorize",
+		TokenURL: base + "/token",
+	}
+}
+
+// NativeClientRedirectURL returns the redirect URL for native clients
+// that copy the response URI by hand
+func (p *Profile) NativeClientRedirectURL() string {
+	return p.Environment().LoginURL + "/" + p.TenantOrDefault() + "/oauth2/nativeclient"
+}
+
+// Config returns the OAuth2 configuration for the profile
+func (p *Profile) Config(redirectURL string) *oauth2.Config {
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = DefaultScopes
+	}
+
+	clientID := p.ClientID
+	if clientID == "" {
+		clientID = os.Getenv("MSCLIENTID")
+	}
+	clientSecret := p.ClientSecret
+	if clientSecret == "" {
+		clientSecret = os.Getenv("MSCLIENTSECRET")
+	}
+
+	return &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       p.qualifyScopes(scopes),
+		Endpoint:     p.Endpoint(),
+		RedirectURL:  redirectURL,
+	}
+}
+
+// qualifyScopes prefixes Graph scopes with the Graph host of a national
+// cloud, the login service assumes the global Graph for short names
+func (p *Profile) qualifyScopes(scopes []string) []string {
+	env := p.Environment()
+
+	result := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		switch {
+		case env.GraphURL == onenote.Global.GraphURL, strings.Contains(s, "/"):
+		case s == "offline_access", s == "openid", s == "profile", s == "email":
+		default:
+			s = env.GraphURL + "/" + s
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
+// ErrNoToken is returned when the token store of a profile does not exist
+var ErrNoToken = errors.New("auth: no token stored for profile, authorize first")
+
+// ReadToken reads the access token from the token store of the profile
+func (p *Profile) ReadToken() ([]byte, error) {
+	token, err := os.ReadFile(p.TokenStore)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.TrimSpace(string(token))), nil
+}
+
+// WriteToken writes out the access token to the token store of the profile
+func (p *Profile) WriteToken(token *oauth2.Token) error {
+	dir := filepath.Dir(p.TokenStore)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p.TokenStore, []byte(token.AccessToken), 0600)
+}
+
+// RandomState returns a securely generated token of size n,
+// used as the OAuth2 state to prevent CSRF attacks
+func RandomState(n int) string {
+	// buffer to store n bytes
+	b := make([]byte, n)
+
+	// read random bytes based on size of b
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+
+	// convert buffer to URL friendly string
+	return base64.URLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bnixon67/onenote"
+)
+
+// ProfilesEnv overrides the location of the profiles file
+const ProfilesEnv = "ONENOTE_PROFILES"
+
+// Profiles is the content of the profiles file, for example
+//
+//	{
+//	  "default": "personal",
+//	  "profiles": {
+//	    "personal": {"tenant": "consumers", "scopes": ["Notes.Read"]},
+//	    "work": {
+//	      "tenant": "contoso.com",
+//	      "cloud": "usgov-l4",
+//	      "clientId": "...",
+//	      "scopes": ["Notes.ReadWrite.All"],
+//	      "owner": "groups/{id}",
+//	      "tokenStore": "work.token"
+//	    }
+//	  }
+//	}
+type Profiles struct {
+	// Default is the profile used when no name is given
+	Default string `json:"default,omitempty"`
+
+	Profiles map[string]*Profile `json:"profiles"`
+
+	// dir is the directory of the profiles file
+	dir string
+}
+
+// ProfilesPath returns the location of the profiles file,
+// $ONENOTE_PROFILES or onenote/profiles.json in the user config directory
+func ProfilesPath() (string, error) {
+	if path, ok := os.LookupEnv(ProfilesEnv); ok {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "onenote", "profiles.json"), nil
+}
+
+// LoadProfiles reads the profiles file at path
+func LoadProfiles(path string) (*Profiles, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := &Profiles{dir: filepath.Dir(path)}
+	if err := json.Unmarshal(b, profiles); err != nil {
+		return nil, fmt.Errorf("auth: cannot parse %s: %w", path, err)
+	}
+
+	return profiles, nil
+}
+
+// Names returns the sorted names of the profiles
+func (p *Profiles) Names() []string {
+	names := make([]string, 0, len(p.Profiles))
+	for name := range p.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the named profile, or the default profile if name is empty
+func (p *Profiles) Get(name string) (*Profile, error) {
+	if name == "" {
+		name = p.Default
+	}
+	if name == "" && len(p.Profiles) == 1 {
+		name = p.Names()[0]
+	}
+	if name == "" {
+		return nil, fmt.Errorf("auth: no default profile, choose one of %s",
+			strings.Join(p.Names(), ", "))
+	}
+
+	profile, ok := p.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("auth: unknown profile %q", name)
+	}
+
+	if _, err := onenote.EnvironmentByName(profile.Cloud); err != nil {
+		return nil, fmt.Errorf("auth: profile %q: %w", name, err)
+	}
+	if profile.Version != "" && profile.Version != onenote.V1 &&
+		profile.Version != onenote.Beta {
+		return nil, fmt.Errorf("auth: profile %q: unknown version %q",
+			name, profile.Version)
+	}
+
+	// return a copy so the caller can adjust it
+	result := *profile
+	result.Name = name
+	result.TokenStore = p.tokenStore(name, profile.TokenStore)
+
+	return &result, nil
+}
+
+// tokenStore returns the path of the token file for the named profile,
+// so each account keeps its own token
+func (p *Profiles) tokenStore(name, store string) string {
+	if store == "" {
+		store = filepath.Join("tokens", name+".token")
+	}
+	if strings.HasPrefix(store, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			store = filepath.Join(home, store[2:])
+		}
+	}
+	if !filepath.IsAbs(store) {
+		store = filepath.Join(p.dir, store)
+	}
+	return store
+}
+
+// LoadProfile returns the named profile from the profiles file.
+// If name is empty and there is no profiles file, the legacy profile is
+// returned: common tenant, MSCLIENTID, Notes.Read and token.txt.
+func LoadProfile(name string) (*Profile, error) {
+	path, err := ProfilesPath()
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := LoadProfiles(path)
+	if errors.Is(err, os.ErrNotExist) && name == "" {
+		return &Profile{TokenStore: DefaultTokenFile}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return profiles.Get(name)
+}
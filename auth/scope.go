@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/bnixon67/onenote"
+	"golang.org/x/oauth2"
+)
+
+// GrantedScopes returns the scopes granted with the token,
+// as returned in the scope field of the token response
+func GrantedScopes(token *oauth2.Token) []string {
+	scope, _ := token.Extra("scope").(string)
+	return strings.Fields(scope)
+}
+
+// MissingScopes returns the scopes in wanted that are not granted,
+// neither directly nor through a more privileged scope
+func MissingScopes(granted, wanted []string) []string {
+	var missing []string
+	for _, w := range wanted {
+		if onenote.HasScope(granted, onenote.Scope(w)) {
+			continue
+		}
+		if containsFold(granted, w) {
+			continue
+		}
+		missing = append(missing, w)
+	}
+	return missing
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Incremental returns a copy of conf that asks the user to consent to
+// only the scopes in wanted that are missing from granted. The Microsoft
+// identity platform adds them to the scopes consented before. The scopes
+// are qualified for the cloud of the profile, as in Config. It returns
+// nil if nothing is missing.
+func (p *Profile) Incremental(conf *oauth2.Config, granted, wanted []string) *oauth2.Config {
+	missing := MissingScopes(granted, wanted)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	result := *conf
+	result.Scopes = p.qualifyScopes(missing)
+	if containsFold(conf.Scopes, "offline_access") {
+		result.Scopes = append(result.Scopes, "offline_access")
+	}
+
+	return &result
+}
+
+// ScopeFor returns the scope to request after the Client returned err,
+// or the empty string if err is not an InsufficientScopeError
+func ScopeFor(err error) string {
+	var scopeErr *onenote.InsufficientScopeError
+	if errors.As(err, &scopeErr) {
+		return string(scopeErr.Scope)
+	}
+	return ""
+}
+
+// Claims returns the decoded claims of the stored access token
+// of the profile, the signature is not verified
+func (p *Profile) Claims() (*onenote.Claims, error) {
+	token, err := p.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+	return onenote.ParseClaims(token)
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestIncremental(t *testing.T) {
+	tests := []struct {
+		name    string
+		cloud   string
+		granted []string
+		wanted  []string
+		want    []string
+	}{
+		{"global", "", []string{"Notes.Read"}, []string{"Notes.ReadWrite"}, []string{"Notes.ReadWrite", "offline_access"}},
+		{"china", "china", []string{"Notes.Read"}, []string{"Notes.Create"},
+			[]string{"https://microsoftgraph.chinacloudapi.cn/Notes.Create", "offline_access"}},
+		{"implied", "", []string{"Notes.ReadWrite"}, []string{"Notes.Read", "Notes.Create"}, nil},
+		{"unknown granted", "usgov-l4", nil, []string{"Notes.Read"},
+			[]string{"https://graph.microsoft.us/Notes.Read", "offline_access"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Profile{Cloud: tt.cloud}
+			conf := &oauth2.Config{Scopes: p.qualifyScopes([]string{"Notes.Read", "offline_access"})}
+
+			got := p.Incremental(conf, tt.granted, tt.wanted)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("Incremental() scopes = %q, want nil", got.Scopes)
+				}
+				return
+			}
+			if got == nil || !reflect.DeepEqual(got.Scopes, tt.want) {
+				t.Fatalf("Incremental() = %+v, want scopes %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package onenote
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the API while a Breaker is
+// open, use errors.Is to check for it
+var ErrCircuitOpen = errors.New("onenote: circuit open, Graph is failing")
+
+// BreakerState is the state of a Breaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets all requests through
+	BreakerClosed BreakerState = iota
+
+	// BreakerOpen fails requests with ErrCircuitOpen
+	BreakerOpen
+
+	// BreakerHalfOpen lets probe requests through to check for recovery
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Defaults of a Breaker
+const (
+	DefaultFailureRatio = 0.5
+	DefaultMinRequests  = 10
+	DefaultWindow       = time.Minute
+	DefaultOpenFor      = 30 * time.Second
+	DefaultProbes       = 1
+)
+
+// Breaker is a circuit breaker that stops requests to Graph during an
+// outage. It opens when the ratio of 5xx responses and timeouts in
+// Window reaches FailureRatio, fails requests with ErrCircuitOpen for
+// OpenFor, then lets Probes requests through in the half-open state.
+// It closes when the probes succeed and opens again when one fails.
+// Like a Limiter, a Breaker may be shared by Clients.
+type Breaker struct {
+	// FailureRatio of failed requests that opens the circuit,
+	// DefaultFailureRatio if zero
+	FailureRatio float64
+
+	// MinRequests is the number of requests in Window needed before the
+	// circuit may open, DefaultMinRequests if zero
+	MinRequests int
+
+	// Window is the period over which failures are counted,
+	// DefaultWindow if zero
+	Window time.Duration
+
+	// OpenFor is how long the circuit stays open before probing,
+	// DefaultOpenFor if zero
+	OpenFor time.Duration
+
+	// Probes is the number of successful half-open requests needed to
+	// close the circuit, DefaultProbes if zero
+	Probes int
+
+	// OnStateChange is called after each change of state, for example
+	// to show that the service is degraded
+	OnStateChange func(from, to BreakerState)
+
+	mu          sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probing     int
+	probed      int
+}
+
+// NewBreaker returns a Breaker that opens at the given ratio of failures
+// and stays open for openFor
+func NewBreaker(failureRatio float64, openFor time.Duration) *Breaker {
+	return &Breaker{FailureRatio: failureRatio, OpenFor: openFor}
+}
+
+// State returns the current state
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.openFor() {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *Breaker) openFor() time.Duration {
+	if b.OpenFor > 0 {
+		return b.OpenFor
+	}
+	return DefaultOpenFor
+}
+
+func (b *Breaker) probes() int {
+	if b.Probes > 0 {
+		return b.Probes
+	}
+	return DefaultProbes
+}
+
+// setState changes the state and returns a function that reports the
+// change, to be called without holding b.mu
+func (b *Breaker) setState(to BreakerState) func() {
+	from := b.state
+	if from == to {
+		return func() {}
+	}
+
+	b.state = to
+	b.requests, b.failures = 0, 0
+	b.windowStart = time.Now()
+	b.probing, b.probed = 0, 0
+	if to == BreakerOpen {
+		b.openedAt = time.Now()
+	}
+
+	fn := b.OnStateChange
+	return func() {
+		if fn != nil {
+			fn(from, to)
+		}
+	}
+}
+
+// allow returns ErrCircuitOpen if a request may not be sent now,
+// a nil Breaker allows all requests
+func (b *Breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	notify := func() {}
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.openFor() {
+		notify = b.setState(BreakerHalfOpen)
+	}
+
+	var err error
+	switch b.state {
+	case BreakerOpen:
+		err = ErrCircuitOpen
+	case BreakerHalfOpen:
+		// only as many probes in flight as are needed to close
+		if b.probing+b.probed >= b.probes() {
+			err = ErrCircuitOpen
+		} else {
+			b.probing++
+		}
+	}
+	b.mu.Unlock()
+
+	notify()
+	return err
+}
+
+// record counts the outcome of a request that allow let through
+func (b *Breaker) record(failed bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	notify := func() {}
+
+	switch b.state {
+	case BreakerHalfOpen:
+		b.probing--
+		if failed {
+			notify = b.setState(BreakerOpen)
+		} else if b.probed++; b.probed >= b.probes() {
+			notify = b.setState(BreakerClosed)
+		}
+
+	case BreakerClosed:
+		window := b.Window
+		if window <= 0 {
+			window = DefaultWindow
+		}
+		if time.Since(b.windowStart) > window {
+			b.windowStart = time.Now()
+			b.requests, b.failures = 0, 0
+		}
+
+		b.requests++
+		if failed {
+			b.failures++
+		}
+
+		ratio := b.FailureRatio
+		if ratio <= 0 {
+			ratio = DefaultFailureRatio
+		}
+		minRequests := b.MinRequests
+		if minRequests <= 0 {
+			minRequests = DefaultMinRequests
+		}
+		if b.requests >= minRequests &&
+			float64(b.failures) >= ratio*float64(b.requests) {
+			notify = b.setState(BreakerOpen)
+		}
+	}
+	b.mu.Unlock()
+
+	notify()
+}
+
+// release gives back the probe slot of a request that allow let through
+// without counting its outcome
+func (b *Breaker) release() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if b.state == BreakerHalfOpen && b.probing > 0 {
+		b.probing--
+	}
+	b.mu.Unlock()
+}
+
+// Middleware returns a Middleware that fails requests with
+// ErrCircuitOpen while the circuit is open and counts the outcome of the
+// requests it lets through
+func (b *Breaker) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := b.allow(); err != nil {
+				return nil, err
+			}
+
+			resp, err := next.RoundTrip(req)
+			if notSent(req, err) {
+				b.release()
+			} else {
+				b.record(breakerFailure(resp, err))
+			}
+			return resp, err
+		})
+	}
+}
+
+// notSent reports whether a request failed without an answer from Graph
+// to count: it was canceled by the caller or never left a Limiter
+func notSent(req *http.Request, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var wait waitError
+	if errors.As(err, &wait) {
+		return true
+	}
+	return errors.Is(err, context.Canceled) && req.Context().Err() != nil
+}
+
+// breakerFailure reports whether the outcome of a sent request counts as
+// a failure of Graph: a 5xx response, a timeout or a failed connection
+func breakerFailure(resp *http.Response, err error) bool {
+	if err == nil {
+		return resp.StatusCode >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,114 @@
+package onenote_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+)
+
+func TestBreaker(t *testing.T) {
+	// a step is one request, answered with status or failed with err
+	type step struct {
+		status  int
+		err     error
+		cancel  bool
+		wait    bool
+		wantErr error
+	}
+
+	tests := []struct {
+		name      string
+		openFor   time.Duration
+		steps     []step
+		wantState onenote.BreakerState
+	}{
+		{"success", time.Hour, []step{{status: 200}, {status: 200}, {status: 200}}, onenote.BreakerClosed},
+		{"5xx opens", time.Hour, []step{{status: 503}, {status: 500}, {status: 200, wantErr: onenote.ErrCircuitOpen}}, onenote.BreakerOpen},
+		{"4xx do not count", time.Hour, []step{{status: 404}, {status: 429}, {status: 400}}, onenote.BreakerClosed},
+		{"below ratio", time.Hour, []step{{status: 200}, {status: 200}, {status: 503}, {status: 200}}, onenote.BreakerClosed},
+		{"network error opens", time.Hour, []step{{err: errors.New("connection refused")}, {err: errors.New("connection refused")}}, onenote.BreakerOpen},
+		{"caller cancel does not count", time.Hour, []step{
+			{err: context.Canceled, cancel: true},
+			{err: context.Canceled, cancel: true},
+			{err: context.Canceled, cancel: true},
+		}, onenote.BreakerClosed},
+		{"limiter wait does not count", time.Hour, []step{
+			{wait: true}, {wait: true}, {wait: true},
+		}, onenote.BreakerClosed},
+		{"probe closes", time.Nanosecond, []step{{status: 503}, {status: 503}, {status: 200}}, onenote.BreakerClosed},
+		{"probe reopens", time.Nanosecond, []step{{status: 503}, {status: 503}, {status: 503}}, onenote.BreakerHalfOpen},
+		{"canceled probe does not close", time.Nanosecond, []step{
+			{status: 503}, {status: 503},
+			{err: context.Canceled, cancel: true},
+		}, onenote.BreakerHalfOpen},
+		{"limited probe does not close", time.Nanosecond, []step{
+			{status: 503}, {status: 503},
+			{wait: true},
+		}, onenote.BreakerHalfOpen},
+		{"canceled probe releases slot", time.Nanosecond, []step{
+			{status: 503}, {status: 503},
+			{err: context.Canceled, cancel: true},
+			{status: 200},
+		}, onenote.BreakerClosed},
+		{"limited probe releases slot", time.Nanosecond, []step{
+			{status: 503}, {status: 503},
+			{wait: true},
+			{status: 200},
+		}, onenote.BreakerClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker := &onenote.Breaker{FailureRatio: 0.5, MinRequests: 2, OpenFor: tt.openFor}
+
+			// the limiter allows one request, then waits a minute
+			limiter := onenote.NewLimiter(1.0/60, 1)
+			if err := limiter.Wait(context.Background(), 1); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, s := range tt.steps {
+				transport := onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					if s.err != nil {
+						return nil, s.err
+					}
+					return &http.Response{StatusCode: s.status, Body: io.NopCloser(strings.NewReader(""))}, nil
+				})
+
+				middlewares := []onenote.Middleware{breaker.Middleware()}
+				ctx, cancel := context.WithCancel(context.Background())
+				if s.cancel {
+					cancel()
+				}
+				if s.wait {
+					middlewares = append(middlewares, limiter.Middleware())
+					ctx, cancel = context.WithTimeout(ctx, time.Millisecond)
+				}
+
+				req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://graph.microsoft.com/v1.0/me/onenote/pages", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, err = onenote.Chain(transport, middlewares...).RoundTrip(req)
+				cancel()
+
+				if s.wantErr != nil && !errors.Is(err, s.wantErr) {
+					t.Errorf("request %d: error = %v, want %v", i, err, s.wantErr)
+				}
+				if s.wait && !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("request %d: error = %v, want %v", i, err, context.DeadlineExceeded)
+				}
+			}
+
+			if got := breaker.State(); got != tt.wantState {
+				t.Errorf("State() = %v, want %v", got, tt.wantState)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package onenote
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits enforced by PageBuilder, to fail before a page is sent that
+// OneNote rejects
+const (
+	// MaxPartSize is the largest image or file part
+	MaxPartSize = 25 << 20
+
+	// MaxRequestSize is the largest request to create a page, with the
+	// HTML and all parts
+	MaxRequestSize = 70 << 20
+
+	// MaxParts is the largest number of image and file parts of a page
+	MaxParts = 50
+)
+
+// PageBuilder builds the HTML and parts to create a page, escaping all
+// text. Methods add content in order to the body, or to the last
+// Outline, and return the builder so calls can be chained:
+//
+//	b := onenote.NewPageBuilder().
+//		Title("Meeting").
+//		Heading(1, "Agenda").
+//		ToDo("Send the notes", false)
+//	html, parts, err := b.Build()
+//	...
+//	page, err := client.CreatePage(ctx, sectionID, html, parts...)
+//
+// The first error, such as a part that is too large, stops the builder
+// and is returned by Build.
+type PageBuilder struct {
+	title   string
+	created time.Time
+
+	body     []chunk
+	outline  bool
+	absolute bool
+
+	parts []Part
+	size  int
+	err   error
+}
+
+// chunk is the HTML of an element of the body, loose if it is neither
+// in an outline nor an image or attachment
+type chunk struct {
+	html  string
+	loose bool
+}
+
+// NewPageBuilder returns an empty PageBuilder
+func NewPageBuilder() *PageBuilder {
+	return &PageBuilder{}
+}
+
+// Title sets the title of the page
+func (b *PageBuilder) Title(title string) *PageBuilder {
+	b.title = title
+	return b
+}
+
+// CreatedAt sets the created time of the page, the time of creation if
+// not set
+func (b *PageBuilder) CreatedAt(t time.Time) *PageBuilder {
+	b.created = t
+	return b
+}
+
+// Outline starts an absolutely positioned outline at x and y pixels from
+// the top left of the page, width pixels wide or automatic if zero. The
+// content added next is within it, until the next Outline. A page with an
+// outline is absolutely positioned, content added outside outlines, other
+// than images and attachments, is then put in an outline at the default
+// position.
+func (b *PageBuilder) Outline(x, y, width int) *PageBuilder {
+	if b.err != nil {
+		return b
+	}
+	b.closeOutline()
+
+	style := fmt.Sprintf("position:absolute;left:%dpx;top:%dpx", x, y)
+	if width > 0 {
+		style += fmt.Sprintf(";width:%dpx", width)
+	}
+	b.add(`<div style="`+style+`">`, false)
+	b.outline = true
+	b.absolute = true
+	return b
+}
+
+// closeOutline ends the current outline if any
+func (b *PageBuilder) closeOutline() {
+	if b.outline {
+		b.add("</div>", false)
+		b.outline = false
+	}
+}
+
+// Paragraph adds a paragraph, new lines in text are line breaks
+func (b *PageBuilder) Paragraph(text string) *PageBuilder {
+	return b.element("<p>" + escapeText(text) + "</p>")
+}
+
+// Heading adds a heading of level 1 to 6
+func (b *PageBuilder) Heading(level int, text string) *PageBuilder {
+	if level < 1 || level > 6 {
+		return b.fail(fmt.Errorf("onenote: heading level %d is not 1 to 6", level))
+	}
+	tag := "h" + strconv.Itoa(level)
+	return b.element("<" + tag + ">" + escapeText(text) + "</" + tag + ">")
+}
+
+// List adds a numbered list if ordered and a bulleted list otherwise
+func (b *PageBuilder) List(ordered bool, items ...string) *PageBuilder {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+
+	var s strings.Builder
+	s.WriteString("<" + tag + ">\n")
+	for _, item := range items {
+		s.WriteString("<li>" + escapeText(item) + "</li>\n")
+	}
+	s.WriteString("</" + tag + ">")
+
+	return b.element(s.String())
+}
+
+// ToDo adds a paragraph with a to-do check box, checked if done
+func (b *PageBuilder) ToDo(text string, checked bool) *PageBuilder {
+	tag := "to-do"
+	if checked {
+		tag = "to-do:completed"
+	}
+	return b.element(`<p data-tag="` + tag + `">` + escapeText(text) + "</p>")
+}
+
+// Table adds a table with a border, one row per slice of cells
+func (b *PageBuilder) Table(rows ...[]string) *PageBuilder {
+	var s strings.Builder
+	s.WriteString(`<table border="1">` + "\n")
+	for _, row := range rows {
+		s.WriteString("<tr>")
+		for _, cell := range row {
+			s.WriteString("<td>" + escapeText(cell) + "</td>")
+		}
+		s.WriteString("</tr>\n")
+	}
+	s.WriteString("</table>")
+
+	return b.element(s.String())
+}
+
+// Image adds the image read from r as a part, alt is its text
+// alternative. The type is detected from the data and must be an image.
+func (b *PageBuilder) Image(r io.Reader, alt string) *PageBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	data, err := b.read(r)
+	if err != nil {
+		return b.fail(err)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return b.fail(fmt.Errorf("onenote: image %q is %s", alt, contentType))
+	}
+
+	name := b.addPart("image", contentType, data)
+	return b.add(`<img src="name:`+name+`" alt="`+html.EscapeString(alt)+`" />`, false)
+}
+
+// Attachment adds the file read from r as a part, shown on the page as
+// an icon with the file name
+func (b *PageBuilder) Attachment(name string, r io.Reader) *PageBuilder {
+	if b.err != nil {
+		return b
+	}
+
+	data, err := b.read(r)
+	if err != nil {
+		return b.fail(err)
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	part := b.addPart("file", contentType, data)
+	return b.add(`<object data-attachment="`+html.EscapeString(name)+
+		`" data="name:`+part+`" type="`+html.EscapeString(contentType)+`" />`, false)
+}
+
+// read returns the data of a part, checking the part limits
+func (b *PageBuilder) read(r io.Reader) ([]byte, error) {
+	if len(b.parts) >= MaxParts {
+		return nil, fmt.Errorf("onenote: more than %d parts", MaxParts)
+	}
+
+	// read one byte more than allowed to find parts that are too large
+	data, err := io.ReadAll(io.LimitReader(r, MaxPartSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("onenote: cannot read part: %w", err)
+	}
+	if len(data) > MaxPartSize {
+		return nil, fmt.Errorf("onenote: part larger than %d bytes", MaxPartSize)
+	}
+	if b.size+len(data) > MaxRequestSize {
+		return nil, fmt.Errorf("onenote: parts larger than %d bytes", MaxRequestSize)
+	}
+	return data, nil
+}
+
+// addPart keeps a part and returns its name, prefix and a number
+func (b *PageBuilder) addPart(prefix, contentType string, data []byte) string {
+	name := prefix + strconv.Itoa(len(b.parts)+1)
+	b.parts = append(b.parts, Part{Name: name, ContentType: contentType, Data: data})
+	b.size += len(data)
+	return name
+}
+
+// element adds the HTML of an element to the current outline, or to
+// the body
+func (b *PageBuilder) element(s string) *PageBuilder {
+	return b.add(s, !b.outline)
+}
+
+// add adds the HTML of an element on its own line
+func (b *PageBuilder) add(s string, loose bool) *PageBuilder {
+	if b.err == nil {
+		b.body = append(b.body, chunk{html: s, loose: loose})
+	}
+	return b
+}
+
+// fail keeps the first error
+func (b *PageBuilder) fail(err error) *PageBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// Build returns the HTML of the page and the parts it refers to, see
+// Client.CreatePage, or the first error
+func (b *PageBuilder) Build() (string, []Part, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+
+	var s strings.Builder
+	s.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	s.WriteString("<title>" + html.EscapeString(b.title) + "</title>\n")
+	if !b.created.IsZero() {
+		s.WriteString(`<meta name="created" content="` + b.created.Format(time.RFC3339) + `" />` + "\n")
+	}
+	if b.absolute {
+		// only div, img and object elements may be in the body
+		s.WriteString("</head>\n<body data-absolute-enabled=\"true\">\n")
+	} else {
+		s.WriteString("</head>\n<body>\n")
+	}
+
+	wrapped := false
+	for _, c := range b.body {
+		if b.absolute && c.loose != wrapped {
+			if wrapped {
+				s.WriteString("</div>\n")
+			} else {
+				s.WriteString("<div>\n")
+			}
+			wrapped = c.loose
+		}
+		s.WriteString(c.html + "\n")
+	}
+	if wrapped || b.outline {
+		s.WriteString("</div>\n")
+	}
+	s.WriteString("</body>\n</html>\n")
+
+	if s.Len()+b.size > MaxRequestSize {
+		return "", nil, fmt.Errorf("onenote: page larger than %d bytes", MaxRequestSize)
+	}
+
+	return s.String(), b.parts, nil
+}
+
+// escapeText escapes text for HTML with new lines as line breaks
+func escapeText(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br />")
+}
@@ -0,0 +1,189 @@
+package onenote_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+)
+
+// png is the start of a PNG image, enough to detect its type
+const png = "\x89PNG\r\n\x1a\n"
+
+func TestPageBuilder(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *onenote.PageBuilder)
+		body  string
+		parts int
+	}{
+		{
+			"empty",
+			func(b *onenote.PageBuilder) {},
+			"<body>\n</body>",
+			0,
+		},
+		{
+			"escaped",
+			func(b *onenote.PageBuilder) {
+				b.Heading(2, "a < b").Paragraph("one\ntwo & three")
+			},
+			"<body>\n<h2>a &lt; b</h2>\n<p>one<br />two &amp; three</p>\n</body>",
+			0,
+		},
+		{
+			"list and to-do",
+			func(b *onenote.PageBuilder) {
+				b.List(true, "x", "y").ToDo("done", true)
+			},
+			"<body>\n<ol>\n<li>x</li>\n<li>y</li>\n</ol>\n" +
+				`<p data-tag="to-do:completed">done</p>` + "\n</body>",
+			0,
+		},
+		{
+			"table",
+			func(b *onenote.PageBuilder) {
+				b.Table([]string{"a", "b"}, []string{"<c>"})
+			},
+			"<body>\n" + `<table border="1">` +
+				"\n<tr><td>a</td><td>b</td></tr>\n<tr><td>&lt;c&gt;</td></tr>\n</table>\n</body>",
+			0,
+		},
+		{
+			"outlines",
+			func(b *onenote.PageBuilder) {
+				b.Outline(10, 20, 300).Paragraph("a").Outline(400, 20, 0).Paragraph("b")
+			},
+			`<body data-absolute-enabled="true">` + "\n" +
+				`<div style="position:absolute;left:10px;top:20px;width:300px">` + "\n<p>a</p>\n</div>\n" +
+				`<div style="position:absolute;left:400px;top:20px">` + "\n<p>b</p>\n</div>\n</body>",
+			0,
+		},
+		{
+			"content before outline",
+			func(b *onenote.PageBuilder) {
+				b.Heading(1, "Top").Paragraph("a").Outline(10, 20, 0).Paragraph("b")
+			},
+			`<body data-absolute-enabled="true">` + "\n<div>\n<h1>Top</h1>\n<p>a</p>\n</div>\n" +
+				`<div style="position:absolute;left:10px;top:20px">` + "\n<p>b</p>\n</div>\n</body>",
+			0,
+		},
+		{
+			"image in body",
+			func(b *onenote.PageBuilder) {
+				b.Paragraph("a").
+					Image(strings.NewReader(png), "pic").
+					Paragraph("b").
+					Outline(0, 0, 0).
+					Attachment("notes.txt", strings.NewReader("text"))
+			},
+			`<body data-absolute-enabled="true">` + "\n<div>\n<p>a</p>\n</div>\n" +
+				`<img src="name:image1" alt="pic" />` + "\n<div>\n<p>b</p>\n</div>\n" +
+				`<div style="position:absolute;left:0px;top:0px">` + "\n" +
+				`<object data-attachment="notes.txt" data="name:file2" type="text/plain; charset=utf-8" />` +
+				"\n</div>\n</body>",
+			2,
+		},
+		{
+			"image without outline",
+			func(b *onenote.PageBuilder) {
+				b.Paragraph("a").Image(strings.NewReader(png), `"pic"`)
+			},
+			"<body>\n<p>a</p>\n" + `<img src="name:image1" alt="&#34;pic&#34;" />` + "\n</body>",
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := onenote.NewPageBuilder().Title("T & C")
+			tt.build(b)
+			html, parts, err := b.Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if !strings.Contains(html, "<title>T &amp; C</title>") {
+				t.Errorf("Build() has no escaped title:\n%s", html)
+			}
+
+			start := strings.Index(html, "<body")
+			end := strings.Index(html, "</body>") + len("</body>")
+			if start < 0 || end < start {
+				t.Fatalf("Build() has no body:\n%s", html)
+			}
+			if body := html[start:end]; body != tt.body {
+				t.Errorf("body =\n%s\nwant\n%s", body, tt.body)
+			}
+			if len(parts) != tt.parts {
+				t.Errorf("len(parts) = %d, want %d", len(parts), tt.parts)
+			}
+		})
+	}
+}
+
+func TestPageBuilderCreated(t *testing.T) {
+	created := time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC)
+	html, _, err := onenote.NewPageBuilder().CreatedAt(created).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<meta name="created" content="2024-03-01T10:15:00Z" />`; !strings.Contains(html, want) {
+		t.Errorf("Build() = %s, want %s", html, want)
+	}
+}
+
+func TestPageBuilderErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(b *onenote.PageBuilder)
+		want  string
+	}{
+		{
+			"heading level",
+			func(b *onenote.PageBuilder) { b.Heading(7, "x") },
+			"heading level 7",
+		},
+		{
+			"not an image",
+			func(b *onenote.PageBuilder) { b.Image(strings.NewReader("text"), "pic") },
+			`image "pic" is text/plain`,
+		},
+		{
+			"part too large",
+			func(b *onenote.PageBuilder) {
+				b.Attachment("big.bin", bytes.NewReader(make([]byte, onenote.MaxPartSize+1)))
+			},
+			"part larger than",
+		},
+		{
+			"too many parts",
+			func(b *onenote.PageBuilder) {
+				for i := 0; i <= onenote.MaxParts; i++ {
+					b.Attachment("a.txt", strings.NewReader("a"))
+				}
+			},
+			"more than 50 parts",
+		},
+		{
+			"first error",
+			func(b *onenote.PageBuilder) { b.Heading(0, "x").Heading(9, "y").Paragraph("z") },
+			"heading level 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := onenote.NewPageBuilder()
+			tt.build(b)
+			html, parts, err := b.Build()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Build() error = %v, want %q", err, tt.want)
+			}
+			if html != "" || parts != nil {
+				t.Errorf("Build() = %q, %d parts, want none", html, len(parts))
+			}
+		})
+	}
+}
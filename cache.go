@@ -0,0 +1,335 @@
+package onenote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores API responses on disk, keyed by account, owner scope and
+// URL. Lists and metadata expire after TTL. Page content is kept until
+// the lastModifiedDateTime of the page changes. Resources never change
+// and do not expire. Writes through the Client invalidate related
+// entries, also for other processes using the same Dir. A Cache may be
+// shared by Clients for different accounts and owners, see
+// Client.Account.
+type Cache struct {
+	// Dir holds one file per entry
+	Dir string
+
+	// TTL is how long lists and metadata are used without refetching
+	TTL time.Duration
+
+	// ContentTTL is how long page content is used without checking
+	// lastModifiedDateTime, zero to check on every request
+	ContentTTL time.Duration
+
+	mu sync.Mutex
+}
+
+// NewCache returns a Cache in dir with the given TTL for lists and metadata
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+// cacheEntry is the file content of an entry, Owner is the account and
+// owner URL of the Client
+type cacheEntry struct {
+	Owner        string    `json:"owner"`
+	URL          string    `json:"url"`
+	Stored       time.Time `json:"stored"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Body         []byte    `json:"body"`
+}
+
+// path returns the file of the entry for owner and urlString
+func (cache *Cache) path(owner, urlString string) string {
+	sum := sha256.Sum256([]byte(owner + "\n" + urlString))
+	return filepath.Join(cache.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// writePath returns the file holding the time of the last write through
+// a Client for key, the owner or the owner and a page id. Entries stored
+// before are stale.
+func (cache *Cache) writePath(key string) string {
+	sum := sha256.Sum256([]byte("write\n" + key))
+	return filepath.Join(cache.Dir, hex.EncodeToString(sum[:])+".write")
+}
+
+// get returns the entry for owner and urlString, nil if not cached
+func (cache *Cache) get(owner, urlString string) *cacheEntry {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	b, err := os.ReadFile(cache.path(owner, urlString))
+	if err != nil {
+		return nil
+	}
+
+	var e cacheEntry
+	if json.Unmarshal(b, &e) != nil || e.Owner != owner || e.URL != urlString {
+		return nil
+	}
+
+	// entries changed by a write are removed when next read
+	if cache.invalidated(&e) {
+		os.Remove(cache.path(owner, urlString))
+		return nil
+	}
+	return &e
+}
+
+// wrote records a write through a Client for owner to the page with the
+// given id, or to a new page if pageID is empty, the caller must not
+// hold cache.mu. The write is recorded in Dir for other processes, the
+// entries are removed if that fails.
+func (cache *Cache) wrote(owner, pageID string) {
+	keys := []string{owner}
+	if pageID != "" {
+		keys = append(keys, owner+"\n"+pageID)
+	}
+
+	cache.mu.Lock()
+	now := []byte(time.Now().Format(time.RFC3339Nano))
+	failed := false
+	for _, key := range keys {
+		if cache.writeFile(cache.writePath(key), now) != nil {
+			failed = true
+		}
+	}
+	cache.mu.Unlock()
+
+	if failed {
+		cache.remove(func(e *cacheEntry) bool {
+			return e.Owner == owner && !strings.Contains(e.URL, "/resources/")
+		})
+	}
+}
+
+// invalidated reports whether a write may have changed the entry since it
+// was stored: the page, and the lists and metadata of the owner since
+// modified times change. Resources never change.
+func (cache *Cache) invalidated(e *cacheEntry) bool {
+	if strings.Contains(e.URL, "/resources/") {
+		return false
+	}
+
+	key := e.Owner
+	if _, rest, ok := strings.Cut(e.URL, "/pages/"); ok {
+		id, _, _ := strings.Cut(rest, "/")
+		id, _, _ = strings.Cut(id, "?")
+		key += "\n" + id
+	}
+
+	b, err := os.ReadFile(cache.writePath(key))
+	if err != nil {
+		return false
+	}
+	written, err := time.Parse(time.RFC3339Nano, string(b))
+	return err == nil && !e.Stored.After(written)
+}
+
+// put stores an entry, errors are ignored since the cache is optional
+func (cache *Cache) put(e *cacheEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	cache.writeFile(cache.path(e.Owner, e.URL), b)
+}
+
+// writeFile replaces the file at path with data, through a temporary
+// file so readers, also in other processes, never see a partial file
+func (cache *Cache) writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(cache.Dir, 0700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(cache.Dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// remove deletes the entries for which match is true
+func (cache *Cache) remove(match func(e *cacheEntry) bool) error {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(cache.Dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var e cacheEntry
+		if json.Unmarshal(b, &e) != nil || match(&e) {
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Invalidate removes the entries for the URL, for all owners
+func (cache *Cache) Invalidate(urlString string) error {
+	return cache.remove(func(e *cacheEntry) bool {
+		return e.URL == urlString
+	})
+}
+
+// InvalidatePrefix removes the entries with URLs starting with prefix,
+// for example the owner URL of a group
+func (cache *Cache) InvalidatePrefix(prefix string) error {
+	return cache.remove(func(e *cacheEntry) bool {
+		return strings.HasPrefix(e.URL, prefix)
+	})
+}
+
+// Clear removes all entries
+func (cache *Cache) Clear() error {
+	return cache.remove(func(*cacheEntry) bool { return true })
+}
+
+// cacheable reports whether the response to r may come from the cache
+func (c *Client) cacheable(r request) bool {
+	return c.Cache != nil && r.method == http.MethodGet && !r.noCache &&
+		r.op != OpGetPageContent
+}
+
+// cachedBody returns a fresh cached response body for urlString
+func (c *Client) cachedBody(op Operation, urlString string) ([]byte, bool) {
+	e := c.Cache.get(c.cacheOwner(), urlString)
+	if e == nil {
+		return nil, false
+	}
+
+	// resources never change
+	if op != OpGetResource && time.Since(e.Stored) > c.Cache.TTL {
+		return nil, false
+	}
+
+	return e.Body, true
+}
+
+// storeBody caches a response body for urlString
+func (c *Client) storeBody(urlString string, body []byte) {
+	c.Cache.put(&cacheEntry{
+		Owner:  c.cacheOwner(),
+		URL:    urlString,
+		Stored: time.Now(),
+		Body:   body,
+	})
+}
+
+// cachedContent returns the page content from the cache if the page has
+// not changed since it was stored, otherwise fetches and stores it
+func (c *Client) cachedContent(ctx context.Context, id string, query url.Values) (string, error) {
+	contentURL := c.ownerURL() + "/pages/" + id + "/content"
+	key := contentURL
+	if query != nil {
+		key += "?" + query.Encode()
+	}
+
+	e := c.Cache.get(c.cacheOwner(), key)
+	if e != nil && c.Cache.ContentTTL > 0 && time.Since(e.Stored) < c.Cache.ContentTTL {
+		return string(e.Body), nil
+	}
+
+	// check lastModifiedDateTime, which is cheaper than the content
+	var meta Page
+	metaQuery := url.Values{"$select": {"lastModifiedDateTime"}}
+	body, err := c.do(ctx, request{
+		op:      OpGetPage,
+		method:  http.MethodGet,
+		url:     c.ownerURL() + "/pages/" + id,
+		query:   metaQuery,
+		noCache: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(body, &meta); err != nil {
+		return "", err
+	}
+
+	if e != nil && e.LastModified == meta.LastModifiedDateTime {
+		e.Stored = time.Now()
+		c.Cache.put(e)
+		return string(e.Body), nil
+	}
+
+	body, err = c.do(ctx, request{
+		op:      OpGetPageContent,
+		method:  http.MethodGet,
+		url:     contentURL,
+		query:   query,
+		noCache: true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	c.Cache.put(&cacheEntry{
+		Owner:        c.cacheOwner(),
+		URL:          key,
+		Stored:       time.Now(),
+		LastModified: meta.LastModifiedDateTime,
+		Body:         body,
+	})
+
+	return string(body), nil
+}
+
+// invalidateWrite marks the entries a write may have changed as stale,
+// see Cache.invalidated. Resolved page paths are removed since titles may
+// have changed.
+func (c *Client) invalidateWrite(pageID string) {
+	if c.Paths != nil {
+		c.Paths.invalidatePages(c.ownerURL())
+	}
+	if c.Cache != nil {
+		c.Cache.wrote(c.cacheOwner(), pageID)
+	}
+}
+
+// cacheOwner returns the owner of the cache entries of the Client, the
+// account and the owner URL. The account is the tenant and object id of
+// the token, Account for other tokens, or a hash of the token if not set.
+func (c *Client) cacheOwner() string {
+	account := c.Account
+	if claims, err := c.parsedClaims.parse(c.Token); err == nil && claims.ObjectID != "" {
+		account = claims.TenantID + "/" + claims.ObjectID
+	} else if account == "" {
+		sum := sha256.Sum256(c.Token)
+		account = "token:" + hex.EncodeToString(sum[:8])
+	}
+	return account + " " + c.ownerURL()
+}
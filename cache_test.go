@@ -0,0 +1,181 @@
+package onenote_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+func TestCacheAccounts(t *testing.T) {
+	alice := jwt(`{"tid":"t1","oid":"alice","scp":"Notes.ReadWrite"}`)
+	bob := jwt(`{"tid":"t1","oid":"bob","scp":"Notes.ReadWrite"}`)
+	opaque := []byte("EwBwA8l6BAAU")
+
+	tests := []struct {
+		name       string
+		token1     []byte
+		account1   string
+		token2     []byte
+		account2   string
+		wantShared bool
+	}{
+		{"same account", alice, "", alice, "", true},
+		{"same account, new token", alice, "work", jwt(`{"tid":"t1","oid":"alice","scp":"Notes.Read"}`), "home", true},
+		{"other account", alice, "", bob, "", false},
+		{"opaque, same profile", opaque, "home", []byte("EwBwA8l6BAAV"), "home", true},
+		{"opaque, other profile", opaque, "home", opaque, "work", false},
+		{"opaque, no profile", opaque, "", []byte("EwBwA8l6BAAV"), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := onenotetest.NewServer()
+			defer srv.Close()
+			srv.AddNotebook("First")
+
+			cache := onenote.NewCache(t.TempDir(), time.Hour)
+			client := func(token []byte, account string) *onenote.Client {
+				c := srv.Client()
+				c.Token, c.Account, c.Cache = token, account, cache
+				return c
+			}
+
+			ctx := context.Background()
+			if _, err := client(tt.token1, tt.account1).ListNotebooks(ctx, nil); err != nil {
+				t.Fatal(err)
+			}
+			srv.AddNotebook("Second")
+
+			response, err := client(tt.token2, tt.account2).ListNotebooks(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if shared := len(response.Value) == 1; shared != tt.wantShared {
+				t.Errorf("got %d notebooks, shared %v, want %v", len(response.Value), shared, tt.wantShared)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidateWrite(t *testing.T) {
+	srv := onenotetest.NewServer()
+	defer srv.Close()
+	nb := srv.AddNotebook("Work")
+	sec := srv.AddSection(nb.Id, "Notes")
+	page := srv.AddPage(sec.Id, "First", "<p>first</p>")
+	other := srv.AddPage(sec.Id, "Other", "<p>other</p>")
+
+	client := srv.Client()
+	client.Cache = onenote.NewCache(t.TempDir(), time.Hour)
+	ctx := context.Background()
+
+	// get lists the pages and gets both pages, from the cache if fresh
+	get := func() (pages int, title, otherTitle string) {
+		t.Helper()
+		list, err := client.ListPages(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := client.GetPage(ctx, page.Id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o, err := client.GetPage(ctx, other.Id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(list.Value), p.Title, o.Title
+	}
+	get()
+
+	tests := []struct {
+		name         string
+		write        func() error
+		wantRequests int
+		wantPages    int
+		wantTitle    string
+	}{
+		{"cached", func() error { return nil }, 0, 2, "First"},
+		{"update page", func() error {
+			return client.UpdatePage(ctx, page.Id, []onenote.PatchCommand{
+				{Target: "title", Action: "replace", Content: "Renamed"},
+			})
+		}, 2, 2, "Renamed"},
+		{"create page", func() error {
+			_, err := client.CreatePage(ctx, sec.Id, "<html><head><title>New</title></head><body></body></html>")
+			return err
+		}, 1, 3, "Renamed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.write(); err != nil {
+				t.Fatal(err)
+			}
+			before := srv.Requests()
+			pages, title, otherTitle := get()
+			if got := srv.Requests() - before; got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+			if pages != tt.wantPages || title != tt.wantTitle || otherTitle != "Other" {
+				t.Errorf("got %d pages, %q and %q, want %d, %q and Other", pages, title, otherTitle, tt.wantPages, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestCacheSharedDir(t *testing.T) {
+	srv := onenotetest.NewServer()
+	defer srv.Close()
+	nb := srv.AddNotebook("Work")
+	sec := srv.AddSection(nb.Id, "Notes")
+	page := srv.AddPage(sec.Id, "First", "<p>first</p>")
+
+	// two Caches in the same directory, as for two processes
+	dir := t.TempDir()
+	reader, writer := srv.Client(), srv.Client()
+	reader.Cache = onenote.NewCache(dir, time.Hour)
+	writer.Cache = onenote.NewCache(dir, time.Hour)
+	ctx := context.Background()
+
+	title := func() string {
+		t.Helper()
+		p, err := reader.GetPage(ctx, page.Id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.Title
+	}
+	title()
+
+	err := writer.UpdatePage(ctx, page.Id, []onenote.PatchCommand{
+		{Target: "title", Action: "replace", Content: "Renamed"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := srv.Requests()
+	if got := title(); got != "Renamed" {
+		t.Errorf("GetPage() title = %q, want Renamed", got)
+	}
+	if got := srv.Requests() - before; got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+
+	// the refetched page is cached again
+	before = srv.Requests()
+	title()
+	if got := srv.Requests() - before; got != 0 {
+		t.Errorf("requests = %d, want 0", got)
+	}
+
+	tmp, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if err != nil || len(tmp) > 0 {
+		t.Errorf("temporary files %q, %v", tmp, err)
+	}
+}
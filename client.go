@@ -0,0 +1,425 @@
+package onenote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultOwner is the owner scope used when Client.Owner is empty
+const DefaultOwner = "me"
+
+// Client holds the settings used to call the OneNote API
+type Client struct {
+	// Token is the OAuth2 access token sent as a Bearer token
+	Token []byte
+
+	// Owner is the owner scope of the notebooks, for example "me",
+	// "users/{id}", "groups/{id}" or "sites/{id}"
+	Owner string
+
+	// Scopes granted to the token, such as "Notes.Read". If not set,
+	// the scopes are read from the token claims when possible.
+	// Operations that need other scopes fail without calling the API.
+	Scopes []string
+
+	// Environment is the Microsoft cloud, Global if not set
+	Environment Environment
+
+	// Version of the Graph API, V1 or Beta, V1 if empty
+	Version string
+
+	// BaseURL overrides Environment and Version, for example to use
+	// a test server, such as "http://127.0.0.1:8080/v1.0"
+	BaseURL string
+
+	// HTTPClient executes the requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+
+	// Middleware wraps the transport of HTTPClient, see Chain
+	Middleware []Middleware
+
+	// Cache stores responses on disk if not nil
+	Cache *Cache
+
+	// Account identifies the signed-in account in the Cache, such as the
+	// profile name, for tokens that are not JWTs. The tenant and object
+	// ids in the claims of JWT access tokens are used otherwise.
+	Account string
+
+	// Limiter limits the rate of requests if not nil, it may be shared
+	// with other Clients
+	Limiter *Limiter
+
+	// Paths keeps the objects found by Resolve if not nil
+	Paths *PathCache
+
+	// Breaker fails requests fast during Graph outages if not nil,
+	// it may be shared with other Clients
+	Breaker *Breaker
+
+	// Strict checks the HTML of CreatePage and UpdatePage with Validate
+	// and ValidatePatch, and returns a *ValidationError without calling
+	// the API if there are problems
+	Strict bool
+
+	// parsedClaims are the claims of Token
+	parsedClaims tokenClaims
+}
+
+// NewClient returns a Client for the notebooks of the signed-in user
+func NewClient(token []byte) *Client {
+	return &Client{Token: token, Owner: DefaultOwner}
+}
+
+// ErrUntrustedURL is returned for absolute URLs, such as the resource
+// URLs of page content, that are not on the host of the Graph base URL,
+// so the token is never sent to other hosts
+var ErrUntrustedURL = errors.New("onenote: URL is not on the Graph host")
+
+// Error is returned when the API responds with an error status
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("onenote: %d %s", e.StatusCode,
+			http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("onenote: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// errorResponse is the error body returned by Microsoft Graph
+type errorResponse struct {
+	Error struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		InnerError struct {
+			RequestID string `json:"request-id"`
+		} `json:"innerError"`
+	} `json:"error"`
+}
+
+// newError builds an Error from a failed HTTP response
+func newError(resp *http.Response, body []byte) *Error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("request-id"),
+	}
+
+	var r errorResponse
+	if json.Unmarshal(body, &r) == nil {
+		e.Code = r.Error.Code
+		e.Message = r.Error.Message
+		if e.RequestID == "" {
+			e.RequestID = r.Error.InnerError.RequestID
+		}
+	}
+
+	return e
+}
+
+// ownerURL returns the base URL of the onenote resources of the owner
+func (c *Client) ownerURL() string {
+	owner := strings.Trim(c.Owner, "/")
+	if owner == "" {
+		owner = DefaultOwner
+	}
+	return c.baseURL() + "/" + owner + "/onenote"
+}
+
+// request describes one call to the OneNote API
+type request struct {
+	op          Operation
+	method      string
+	url         string
+	query       url.Values
+	contentType string
+	body        []byte
+
+	// noCache skips the cache
+	noCache bool
+}
+
+// do is a helper function to form and execute the HTTP request
+// and return the HTTP response body
+func (c *Client) do(ctx context.Context, r request) ([]byte, error) {
+	// fail early if the token is known to lack the scope
+	if err := c.checkScope(r.op); err != nil {
+		return nil, err
+	}
+
+	// parse the URL string
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return nil, err
+	}
+
+	// only send the token to the Graph host
+	if err := c.checkHost(u); err != nil {
+		return nil, err
+	}
+
+	// add the query parameters to the URL
+	if r.query != nil {
+		u.RawQuery = r.query.Encode()
+	}
+
+	if c.cacheable(r) {
+		if body, ok := c.cachedBody(r.op, u.String()); ok {
+			return body, nil
+		}
+	}
+
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+
+	// create the HTTP request with proper headers
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+string(c.Token))
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+
+	// execute HTTP request
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// read HTTP response
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := newError(resp, respBody)
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			return nil, c.unauthorizedError(apiErr)
+		case http.StatusForbidden:
+			return nil, c.scopeError(r.op, apiErr)
+		}
+		return nil, apiErr
+	}
+
+	if c.cacheable(r) {
+		c.storeBody(u.String(), respBody)
+	}
+
+	// return HTTP response body
+	return respBody, nil
+}
+
+// checkHost returns ErrUntrustedURL if u does not have the scheme and
+// host of the base URL
+func (c *Client) checkHost(u *url.URL) error {
+	base, err := url.Parse(c.baseURL())
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) {
+		return fmt.Errorf("%w: %s://%s", ErrUntrustedURL, u.Scheme, u.Host)
+	}
+	return nil
+}
+
+// get executes a GET request for op
+func (c *Client) get(ctx context.Context, op Operation, urlString string, query url.Values) ([]byte, error) {
+	return c.do(ctx, request{op: op, method: http.MethodGet, url: urlString, query: query})
+}
+
+// getJSON executes a GET request for op and unmarshals the response into v
+func (c *Client) getJSON(ctx context.Context, op Operation, urlString string, query url.Values, v interface{}) error {
+	return c.doJSON(ctx, request{op: op, method: http.MethodGet, url: urlString, query: query}, v)
+}
+
+// doJSON executes the request and unmarshals the response into v
+func (c *Client) doJSON(ctx context.Context, r request, v interface{}) error {
+	body, err := c.do(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("onenote: cannot unmarshal: %w", err)
+	}
+
+	return nil
+}
+
+// ListNotebooks retrieves a list of Notebook objects
+func (c *Client) ListNotebooks(ctx context.Context, query url.Values) (NotebookResponse, error) {
+	var response NotebookResponse
+	err := c.getJSON(ctx, OpListNotebooks, c.ownerURL()+"/notebooks", query, &response)
+	return response, err
+}
+
+// ListSectionGroups retrieves a list of SectionGroup objects
+func (c *Client) ListSectionGroups(ctx context.Context, query url.Values) (SectionGroupResponse, error) {
+	var response SectionGroupResponse
+	err := c.getJSON(ctx, OpListSectionGroups, c.ownerURL()+"/sectionGroups", query, &response)
+	return response, err
+}
+
+// ListSections retrieves a list of Section objects
+func (c *Client) ListSections(ctx context.Context, query url.Values) (SectionResponse, error) {
+	var response SectionResponse
+	err := c.getJSON(ctx, OpListSections, c.ownerURL()+"/sections", query, &response)
+	return response, err
+}
+
+// ListPages retrieves a list of Page objects
+func (c *Client) ListPages(ctx context.Context, query url.Values) (PageResponse, error) {
+	var response PageResponse
+	err := c.getJSON(ctx, OpListPages, c.ownerURL()+"/pages", query, &response)
+	return response, err
+}
+
+// GetPage retrieves the Page with the given id
+func (c *Client) GetPage(ctx context.Context, id string, query url.Values) (Page, error) {
+	var response Page
+	err := c.getJSON(ctx, OpGetPage, c.ownerURL()+"/pages/"+id, query, &response)
+	return response, err
+}
+
+// GetPageContent retrieves the HTML content of the Page with the given id
+func (c *Client) GetPageContent(ctx context.Context, id string, query url.Values) (string, error) {
+	if c.Cache != nil {
+		return c.cachedContent(ctx, id, query)
+	}
+
+	body, err := c.get(ctx, OpGetPageContent, c.ownerURL()+"/pages/"+id+"/content", query)
+	return string(body), err
+}
+
+// GetResource retrieves the binary data of an image or file resource,
+// ref is either the resource id or the URL from the page content
+func (c *Client) GetResource(ctx context.Context, ref string) ([]byte, error) {
+	urlString := ref
+	if !isAbsolute(ref) {
+		urlString = c.ownerURL() + "/resources/" + ref + "/$value"
+	}
+	return c.get(ctx, OpGetResource, urlString, nil)
+}
+
+// CreatePage creates a page from the HTML in the section with the given id,
+// or in the default section if sectionID is empty. The parts are sent
+// with the HTML as multipart/form-data for the images and files it
+// refers to as "name:" and the part Name.
+func (c *Client) CreatePage(ctx context.Context, sectionID string, html string, parts ...Part) (Page, error) {
+	urlString := c.ownerURL() + "/pages"
+	if sectionID != "" {
+		urlString = c.ownerURL() + "/sections/" + sectionID + "/pages"
+	}
+
+	if c.Strict {
+		if problems := Validate(html, parts); len(problems) > 0 {
+			return Page{}, &ValidationError{Problems: problems}
+		}
+	}
+
+	contentType, data := "text/html", []byte(html)
+	if len(parts) > 0 {
+		var err error
+		contentType, data, err = multipartBody("Presentation", "text/html", data, parts)
+		if err != nil {
+			return Page{}, err
+		}
+	}
+
+	body, err := c.do(ctx, request{
+		op:          OpCreatePage,
+		method:      http.MethodPost,
+		url:         urlString,
+		contentType: contentType,
+		body:        data,
+	})
+	if err != nil {
+		return Page{}, err
+	}
+	c.invalidateWrite("")
+
+	var page Page
+	if err := json.Unmarshal(body, &page); err != nil {
+		return Page{}, fmt.Errorf("onenote: cannot unmarshal: %w", err)
+	}
+
+	return page, nil
+}
+
+// PatchCommand is one change to the content of a page,
+// see https://learn.microsoft.com/graph/onenote-update-page
+type PatchCommand struct {
+	// Target is "body", "title", "#data-id" or a generated id
+	Target string `json:"target"`
+
+	// Action is "append", "insert", "prepend" or "replace"
+	Action string `json:"action"`
+
+	// Position is "before" or "after", optional
+	Position string `json:"position,omitempty"`
+
+	// Content is the HTML to add
+	Content string `json:"content"`
+}
+
+// UpdatePage changes the content of the page with the given id
+func (c *Client) UpdatePage(ctx context.Context, id string, commands []PatchCommand) error {
+	if c.Strict {
+		if problems := ValidatePatch(commands); len(problems) > 0 {
+			return &ValidationError{Problems: problems}
+		}
+	}
+
+	body, err := json.Marshal(commands)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(ctx, request{
+		op:          OpUpdatePage,
+		method:      http.MethodPatch,
+		url:         c.ownerURL() + "/pages/" + id + "/content",
+		contentType: "application/json",
+		body:        body,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.invalidateWrite(id)
+	return nil
+}
+
+// DeletePage deletes the page with the given id
+func (c *Client) DeletePage(ctx context.Context, id string) error {
+	_, err := c.do(ctx, request{
+		op:     OpDeletePage,
+		method: http.MethodDelete,
+		url:    c.ownerURL() + "/pages/" + id,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.invalidateWrite(id)
+	return nil
+}
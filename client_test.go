@@ -0,0 +1,136 @@
+package onenote_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+func TestGetResourceHost(t *testing.T) {
+	srv := onenotetest.NewServer()
+	defer srv.Close()
+
+	var leaked []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.Header.Get("Authorization"))
+	}))
+	defer other.Close()
+
+	id := srv.AddResource("image/png", []byte("png"))
+
+	tests := []struct {
+		name    string
+		ref     string
+		wantErr error
+	}{
+		{"id", id, nil},
+		{"same host", srv.BaseURL() + "/me/onenote/resources/" + id + "/$value", nil},
+		{"other host", other.URL + "/v1.0/me/onenote/resources/" + id + "/$value", onenote.ErrUntrustedURL},
+		{"other scheme", "https" + srv.BaseURL()[len("http"):] + "/me/onenote/resources/" + id + "/$value", onenote.ErrUntrustedURL},
+	}
+
+	client := srv.Client()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := client.GetResource(context.Background(), tt.ref)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetResource() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(data) != "png" {
+				t.Errorf("GetResource() = %q, want %q", data, "png")
+			}
+		})
+	}
+
+	if len(leaked) > 0 {
+		t.Errorf("other host got %d requests", len(leaked))
+	}
+}
+
+func TestScopeError(t *testing.T) {
+	tests := []struct {
+		name      string
+		owner     string
+		scopes    []string
+		code      string
+		wantScope onenote.Scope
+		wantSent  bool
+	}{
+		{"unknown scopes", "", nil, "40003", "", true},
+		{"unknown scopes, scope code", "", nil, "40004", onenote.NotesRead, true},
+		{"unknown scopes, request denied", "", nil, "Authorization_RequestDenied", onenote.NotesRead, true},
+		{"granted", "", []string{"Notes.ReadWrite"}, "40004", "", true},
+		{"create lists", "", []string{"Notes.Create"}, "40003", "", true},
+		{"missing", "", []string{"User.Read"}, "40003", onenote.NotesRead, false},
+		{"user", "users/u1", []string{"Notes.Read"}, "40003", onenote.NotesReadAll, false},
+		{"group", "groups/g1", []string{"Notes.ReadWrite"}, "40003", onenote.NotesReadAll, false},
+		{"site", "sites/s1", []string{"Notes.Create"}, "40003", onenote.NotesReadAll, false},
+		{"group granted", "groups/g1", []string{"Notes.Read.All"}, "40004", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := onenotetest.NewServer()
+			defer srv.Close()
+			srv.InjectFault(onenotetest.Fault{Status: http.StatusForbidden, Code: tt.code})
+
+			client := srv.Client()
+			client.Scopes = tt.scopes
+			if tt.owner != "" {
+				client.Owner = tt.owner
+			}
+			_, err := client.ListNotebooks(context.Background(), nil)
+
+			var scopeErr *onenote.InsufficientScopeError
+			if got := errors.As(err, &scopeErr); got != (tt.wantScope != "") {
+				t.Fatalf("ListNotebooks() error = %v, InsufficientScopeError %v, want %v", err, got, tt.wantScope != "")
+			}
+
+			var apiErr *onenote.Error
+			if sent := errors.As(err, &apiErr); sent != tt.wantSent {
+				t.Errorf("ListNotebooks() error = %v, sent %v, want %v", err, sent, tt.wantSent)
+			}
+			if tt.wantScope != "" && scopeErr.Scope != tt.wantScope {
+				t.Errorf("Scope = %s, want %s", scopeErr.Scope, tt.wantScope)
+			}
+		})
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	tests := []struct {
+		op      onenote.Operation
+		owner   string
+		granted []string
+		want    onenote.Scope
+		allowed bool
+	}{
+		{onenote.OpListNotebooks, "me", []string{"Notes.Create"}, onenote.NotesRead, true},
+		{onenote.OpGetPage, "/me/", []string{"Notes.Create"}, onenote.NotesRead, true},
+		{onenote.OpGetPageContent, "me", []string{"Notes.Create"}, onenote.NotesRead, false},
+		{onenote.OpGetPageContent, "", []string{"Notes.Read"}, onenote.NotesRead, true},
+		{onenote.OpCreatePage, "me", []string{"Notes.Read"}, onenote.NotesCreate, false},
+		{onenote.OpUpdatePage, "me", []string{"Notes.Create"}, onenote.NotesReadWrite, false},
+		{onenote.OpListSections, "users/u1", []string{"Notes.Create"}, onenote.NotesReadAll, false},
+		{onenote.OpListSections, "groups/g1", []string{"Notes.ReadWrite"}, onenote.NotesReadAll, false},
+		{onenote.OpListPages, "sites/s1", []string{"Notes.Read.All"}, onenote.NotesReadAll, true},
+		{onenote.OpCreatePage, "groups/g1", []string{"Notes.Create"}, onenote.NotesReadWriteAll, false},
+		{onenote.OpDeletePage, "sites/s1", []string{"https://graph.microsoft.com/Notes.ReadWrite.All"}, onenote.NotesReadWriteAll, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.op)+" "+tt.owner, func(t *testing.T) {
+			if got := onenote.RequiredScope(tt.op, tt.owner); got != tt.want {
+				t.Errorf("RequiredScope() = %s, want %s", got, tt.want)
+			}
+			if got := onenote.Allowed(tt.granted, tt.op, tt.owner); got != tt.allowed {
+				t.Errorf("Allowed(%q) = %v, want %v", tt.granted, got, tt.allowed)
+			}
+		})
+	}
+}
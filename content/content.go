@@ -0,0 +1,332 @@
+// Package content parses the HTML returned for OneNote pages by
+// onenote.Client.GetPageContent into typed nodes.
+//
+// A page holds absolutely positioned outlines, images and attachments.
+// Outlines hold paragraphs, headings, lists, tables, images and
+// attachments. Every node keeps the id generated by OneNote and the
+// data-id given when it was created, so it can be the target of a PATCH,
+// and the tags, such as to-do, set on it.
+//
+//	html, err := client.GetPageContent(ctx, id, nil)
+//	...
+//	page, err := content.ParseString(html)
+//	...
+//	for _, outline := range page.Outlines() {
+//		...
+//	}
+package content
+
+import (
+	"strings"
+	"time"
+)
+
+// Page is the parsed content of a OneNote page
+type Page struct {
+	// Title of the page
+	Title string
+
+	// Lang is the language of the page, such as "en-US"
+	Lang string
+
+	// Created is the created meta value as returned
+	Created string
+
+	// Body holds the attributes of the body element
+	Body Attrs
+
+	// Blocks are the outlines, images and attachments on the page
+	Blocks []Block
+}
+
+// CreatedTime returns Created as a time, the zero time if it cannot be
+// parsed
+func (p *Page) CreatedTime() time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.9999999", "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, p.Created); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Outlines returns the outlines of the page in document order
+func (p *Page) Outlines() []*Outline {
+	var outlines []*Outline
+	for _, b := range p.Blocks {
+		if o, ok := b.(*Outline); ok {
+			outlines = append(outlines, o)
+		}
+	}
+	return outlines
+}
+
+// Find returns the node with the PATCH target, "#" and a data-id or a
+// generated id, nil if there is none
+func (p *Page) Find(target string) Block {
+	var found Block
+	Walk(p.Blocks, func(b Block) bool {
+		if found != nil {
+			return false
+		}
+		a := b.Attributes()
+		if (strings.HasPrefix(target, "#") && a.DataID == target[1:]) ||
+			(a.ID != "" && a.ID == target) {
+			found = b
+		}
+		return true
+	})
+	return found
+}
+
+// Attrs are the attributes common to all nodes
+type Attrs struct {
+	// ID is generated by OneNote, such as "p:{guid}{12}"
+	ID string
+
+	// DataID is the data-id given when the node was created
+	DataID string
+
+	// Tags are the note tags on the node, such as to-do
+	Tags []Tag
+
+	// Style is the inline CSS of the node
+	Style string
+}
+
+// Attributes returns a, nodes embed Attrs to implement Block
+func (a *Attrs) Attributes() *Attrs {
+	return a
+}
+
+// Target returns the PATCH target for the node, the data-id if set
+// and the generated id otherwise
+func (a *Attrs) Target() string {
+	if a.DataID != "" {
+		return "#" + a.DataID
+	}
+	return a.ID
+}
+
+// HasTag reports whether the node has the tag, such as "to-do"
+func (a *Attrs) HasTag(name string) bool {
+	for _, t := range a.Tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Tag is a note tag from a data-tag attribute, such as "to-do" or
+// "to-do:completed"
+type Tag struct {
+	// Name is the tag without the completed state, such as "to-do"
+	Name string
+
+	// Completed is true for checked to-do style tags
+	Completed bool
+}
+
+// String returns the tag as in a data-tag attribute
+func (t Tag) String() string {
+	if t.Completed {
+		return t.Name + ":completed"
+	}
+	return t.Name
+}
+
+// parseTags returns the tags of a data-tag attribute, which is a comma
+// separated list
+func parseTags(value string) []Tag {
+	var tags []Tag
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		name, state, _ := strings.Cut(v, ":")
+		tags = append(tags, Tag{Name: name, Completed: state == "completed"})
+	}
+	return tags
+}
+
+// Position is the placement of an absolutely positioned node in pixels,
+// zero for unset values
+type Position struct {
+	Left, Top, Width, Height int
+}
+
+// Block is a node of a page
+type Block interface {
+	Attributes() *Attrs
+}
+
+// Outline is an absolutely positioned div holding blocks
+type Outline struct {
+	Attrs
+	Position Position
+	Blocks   []Block
+}
+
+// Paragraph is a p element, or text directly in an outline, list item
+// or table cell
+type Paragraph struct {
+	Attrs
+	Runs []Run
+}
+
+// Text returns the text of the paragraph
+func (p *Paragraph) Text() string {
+	return RunsText(p.Runs)
+}
+
+// Heading is an h1 to h6 element
+type Heading struct {
+	Attrs
+	Level int
+	Runs  []Run
+}
+
+// Text returns the text of the heading
+func (h *Heading) Text() string {
+	return RunsText(h.Runs)
+}
+
+// List is a ul or ol element
+type List struct {
+	Attrs
+	Ordered bool
+	Items   []*ListItem
+}
+
+// ListItem is an li element, nested lists are in Blocks
+type ListItem struct {
+	Attrs
+	Runs   []Run
+	Blocks []Block
+}
+
+// Text returns the text of the list item without nested blocks
+func (li *ListItem) Text() string {
+	return RunsText(li.Runs)
+}
+
+// Table is a table element
+type Table struct {
+	Attrs
+	Rows []*Row
+}
+
+// Row is a tr element
+type Row struct {
+	Attrs
+	Cells []*Cell
+}
+
+// Cell is a td or th element, ColSpan and RowSpan are the number of
+// columns and rows it covers, at least 1 and at most 1000 columns and
+// 65534 rows
+type Cell struct {
+	Attrs
+	Header           bool
+	ColSpan, RowSpan int
+	Blocks           []Block
+}
+
+// Text returns the text of the blocks in the cell, see Text
+func (c *Cell) Text() string {
+	return Text(c)
+}
+
+// Image is an img element, Src and FullResSrc are resource URLs that
+// onenote.Client.GetResource accepts
+type Image struct {
+	Attrs
+	Src            string
+	SrcType        string
+	FullResSrc     string
+	FullResSrcType string
+	Alt            string
+	Width, Height  int
+
+	// Position is set for images placed on the page
+	Position *Position
+}
+
+// Object is an attached file, Data is a resource URL
+type Object struct {
+	Attrs
+	Name string
+	Type string
+	Data string
+
+	// Position is set for attachments placed on the page
+	Position *Position
+}
+
+// Raw is an element with no typed node, such as an iframe
+type Raw struct {
+	Attrs
+	Tag  string
+	HTML string
+}
+
+// Run is text with the same formatting, Href is set for links and
+// Break for line breaks
+type Run struct {
+	Text string
+	Format
+	Href  string
+	Break bool
+}
+
+// Format is the character formatting of a Run
+type Format struct {
+	Bold, Italic, Underline, Strike bool
+	Superscript, Subscript, Code    bool
+}
+
+// RunsText returns the text of runs, with line breaks as newlines
+func RunsText(runs []Run) string {
+	var b strings.Builder
+	for _, r := range runs {
+		if r.Break {
+			b.WriteByte('\n')
+			continue
+		}
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+// Walk calls fn for each block and the blocks nested in it, depth-first
+// in document order. Returning false from fn skips the nested blocks.
+func Walk(blocks []Block, fn func(Block) bool) {
+	for _, b := range blocks {
+		if !fn(b) {
+			continue
+		}
+
+		switch t := b.(type) {
+		case *Outline:
+			Walk(t.Blocks, fn)
+		case *List:
+			for _, item := range t.Items {
+				Walk([]Block{item}, fn)
+			}
+		case *ListItem:
+			Walk(t.Blocks, fn)
+		case *Table:
+			for _, row := range t.Rows {
+				Walk([]Block{row}, fn)
+			}
+		case *Row:
+			for _, cell := range t.Cells {
+				Walk([]Block{cell}, fn)
+			}
+		case *Cell:
+			Walk(t.Blocks, fn)
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is how an element changed between two versions of a page
+type ChangeKind string
+
+const (
+	Added     ChangeKind = "added"
+	Removed   ChangeKind = "removed"
+	Modified  ChangeKind = "modified"
+	Checked   ChangeKind = "checked"
+	Unchecked ChangeKind = "unchecked"
+)
+
+// Change is an element of a page that was added, removed or changed
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+
+	// Element is "title", "paragraph", "heading", "list item", "table",
+	// "image" or "attachment"
+	Element string `json:"element"`
+
+	// Target is the PATCH target of the element, in the new version
+	// unless it was removed
+	Target string `json:"target,omitempty"`
+
+	// Old and New are the lines of the element, as shown by WriteText,
+	// Old is empty if added and New if removed
+	Old []string `json:"old,omitempty"`
+	New []string `json:"new,omitempty"`
+
+	// OldBlock and NewBlock are the elements, nil for the title or if
+	// added or removed
+	OldBlock Block `json:"-"`
+	NewBlock Block `json:"-"`
+}
+
+// PageDiff is the difference between two versions of a page
+type PageDiff struct {
+	OldTitle string   `json:"oldTitle"`
+	NewTitle string   `json:"newTitle"`
+	Changes  []Change `json:"changes"`
+}
+
+// Diff compares two versions of a page, such as snapshots taken days
+// apart. Elements are matched by their data-id or generated id, and by
+// their text if they have neither. Paragraphs, headings and list items
+// are compared by text, to-do tags by their check state, tables by their
+// cells and images by their source, alt text and size. The changes are
+// in the order of the new version, with removed elements where they were.
+func Diff(before, after *Page) *PageDiff {
+	d := &PageDiff{OldTitle: before.Title, NewTitle: after.Title, Changes: []Change{}}
+
+	if before.Title != after.Title {
+		d.Changes = append(d.Changes, Change{
+			Kind:    Modified,
+			Element: "title",
+			Old:     []string{before.Title},
+			New:     []string{after.Title},
+		})
+	}
+
+	a, b := diffItems(before), diffItems(after)
+	match := matchItems(a, b)
+
+	// removed elements before the next element matched to a later one
+	next := 0
+	removedUpTo := func(end int) {
+		for ; next < end; next++ {
+			if _, ok := match.old[next]; !ok {
+				d.Changes = append(d.Changes, Change{
+					Kind:     Removed,
+					Element:  a[next].element,
+					Target:   a[next].block.Attributes().Target(),
+					Old:      a[next].lines,
+					OldBlock: a[next].block,
+				})
+			}
+		}
+	}
+
+	for j, item := range b {
+		i, ok := match.new[j]
+		if !ok {
+			d.Changes = append(d.Changes, Change{
+				Kind:     Added,
+				Element:  item.element,
+				Target:   item.block.Attributes().Target(),
+				New:      item.lines,
+				NewBlock: item.block,
+			})
+			continue
+		}
+
+		if i >= next {
+			removedUpTo(i)
+			next = i + 1
+		}
+
+		if c, changed := compareItems(a[i], item); changed {
+			d.Changes = append(d.Changes, c)
+		}
+	}
+	removedUpTo(len(a))
+
+	return d
+}
+
+// diffItem is an element compared by Diff
+type diffItem struct {
+	element string
+	block   Block
+	key     string
+
+	// lines are shown for the element, text is compared when it has
+	// no key
+	lines []string
+	text  string
+
+	// todo is set for elements with a to-do style tag
+	todo, completed bool
+}
+
+// diffItems returns the elements of the page to compare in reading order
+func diffItems(p *Page) []diffItem {
+	var items []diffItem
+
+	add := func(element string, b Block, lines []string) {
+		item := diffItem{
+			element: element,
+			block:   b,
+			key:     b.Attributes().Target(),
+			lines:   lines,
+			text:    element + "\n" + strings.Join(lines, "\n"),
+		}
+		for _, tag := range b.Attributes().Tags {
+			if strings.HasPrefix(tag.Name, "to-do") {
+				item.todo, item.completed = true, tag.Completed
+			}
+		}
+		items = append(items, item)
+	}
+
+	Walk(ReadingOrder(p.Blocks), func(b Block) bool {
+		switch t := b.(type) {
+		case *Paragraph:
+			add("paragraph", b, textLines(t.Text()))
+		case *Heading:
+			add("heading", b, textLines(t.Text()))
+		case *ListItem:
+			add("list item", b, textLines(t.Text()))
+		case *Table:
+			var lines []string
+			for _, row := range t.Grid() {
+				for i := range row {
+					row[i] = strings.ReplaceAll(row[i], "\n", " ")
+				}
+				lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+			}
+			add("table", b, lines)
+			return false
+		case *Image:
+			line := "![" + t.Alt + "](" + imageSource(t) + ")"
+			if t.Width > 0 || t.Height > 0 {
+				line += " " + strconv.Itoa(t.Width) + "x" + strconv.Itoa(t.Height)
+			}
+			add("image", b, []string{line})
+		case *Object:
+			add("attachment", b, []string{"[" + t.Name + "](" + t.Data + ")"})
+		}
+		return true
+	})
+
+	// keys used more than once cannot match, their text is used instead
+	count := make(map[string]int)
+	for _, item := range items {
+		count[item.key]++
+	}
+	for i := range items {
+		if count[items[i].key] > 1 {
+			items[i].key = ""
+		}
+	}
+
+	return items
+}
+
+// textLines returns the lines of text with white space collapsed
+func textLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		lines = append(lines, collapseLine(line))
+	}
+	return lines
+}
+
+// matching maps the indexes of matched items of the old and new version
+type matching struct {
+	old map[int]int
+	new map[int]int
+}
+
+// matchItems matches the items with the same key, then the remaining
+// items with the same text in order
+func matchItems(a, b []diffItem) matching {
+	m := matching{old: make(map[int]int), new: make(map[int]int)}
+
+	byKey := make(map[string]int)
+	for i, item := range a {
+		if item.key != "" {
+			byKey[item.key] = i
+		}
+	}
+	for j, item := range b {
+		if i, ok := byKey[item.key]; ok && item.key != "" && a[i].element == item.element {
+			m.old[i], m.new[j] = j, i
+		}
+	}
+
+	// the longest common sequence of the text of the others
+	var ai, bi []int
+	for i := range a {
+		if _, ok := m.old[i]; !ok {
+			ai = append(ai, i)
+		}
+	}
+	for j := range b {
+		if _, ok := m.new[j]; !ok {
+			bi = append(bi, j)
+		}
+	}
+	for _, p := range lcs(len(ai), len(bi), func(x, y int) bool {
+		return a[ai[x]].text == b[bi[y]].text
+	}) {
+		i, j := ai[p[0]], bi[p[1]]
+		m.old[i], m.new[j] = j, i
+	}
+
+	return m
+}
+
+// lcs returns the index pairs of a longest common subsequence of two
+// sequences of length n and m, eq compares their elements
+func lcs(n, m int, eq func(x, y int) bool) [][2]int {
+	// length[x][y] is the length for the sequences from x and y
+	length := make([][]int, n+1)
+	for x := range length {
+		length[x] = make([]int, m+1)
+	}
+	for x := n - 1; x >= 0; x-- {
+		for y := m - 1; y >= 0; y-- {
+			switch {
+			case eq(x, y):
+				length[x][y] = length[x+1][y+1] + 1
+			case length[x+1][y] >= length[x][y+1]:
+				length[x][y] = length[x+1][y]
+			default:
+				length[x][y] = length[x][y+1]
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for x, y := 0, 0; x < n && y < m; {
+		switch {
+		case eq(x, y):
+			pairs = append(pairs, [2]int{x, y})
+			x++
+			y++
+		case length[x+1][y] >= length[x][y+1]:
+			x++
+		default:
+			y++
+		}
+	}
+	return pairs
+}
+
+// compareItems returns the change between matched items, if any
+func compareItems(a, b diffItem) (Change, bool) {
+	c := Change{
+		Element:  b.element,
+		Target:   b.block.Attributes().Target(),
+		Old:      a.lines,
+		New:      b.lines,
+		OldBlock: a.block,
+		NewBlock: b.block,
+	}
+
+	switch {
+	case a.text != b.text:
+		c.Kind = Modified
+	case a.todo && b.todo && a.completed != b.completed:
+		c.Kind = Unchecked
+		if b.completed {
+			c.Kind = Checked
+		}
+	case a.todo != b.todo:
+		c.Kind = Modified
+	default:
+		return c, false
+	}
+	return c, true
+}
+
+// WriteText writes the changes like a unified diff, with a hunk for each
+// changed element and its lines prefixed by "-" if removed, "+" if added
+// and " " if unchanged. To-do elements start with "[ ]" or "[x]".
+func (d *PageDiff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.OldTitle, d.NewTitle)
+
+	for _, c := range d.Changes {
+		header := c.Element
+		if c.Target != "" {
+			header += " " + c.Target
+		}
+		fmt.Fprintf(&b, "@@ %s %s @@\n", c.Kind, header)
+
+		for _, line := range diffLines(todoLines(c.Old, c.OldBlock), todoLines(c.New, c.NewBlock)) {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// todoLines returns lines with the check box of a to-do element
+func todoLines(lines []string, b Block) []string {
+	if b == nil {
+		return lines
+	}
+	box, ok := checkbox(b.Attributes())
+	if !ok || len(lines) == 0 {
+		return lines
+	}
+	return append([]string{box + lines[0]}, lines[1:]...)
+}
+
+// diffLines returns the lines of a and b prefixed by "-", "+" or " "
+func diffLines(a, b []string) []string {
+	var result []string
+	x, y := 0, 0
+	for _, p := range lcs(len(a), len(b), func(x, y int) bool { return a[x] == b[y] }) {
+		for ; x < p[0]; x++ {
+			result = append(result, "-"+a[x])
+		}
+		for ; y < p[1]; y++ {
+			result = append(result, "+"+b[y])
+		}
+		result = append(result, " "+a[x])
+		x++
+		y++
+	}
+	for ; x < len(a); x++ {
+		result = append(result, "-"+a[x])
+	}
+	for ; y < len(b); y++ {
+		result = append(result, "+"+b[y])
+	}
+	return result
+}
+
+// WriteJSON writes the titles and changes as JSON
+func (d *PageDiff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,187 @@
+package content_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote/content"
+)
+
+// parseVersion returns the page with the title and the body in one
+// outline
+func parseVersion(t *testing.T, title, body string) *content.Page {
+	t.Helper()
+	html := strings.Replace(page, "<title>Notes</title>", "<title>"+title+"</title>", 1) +
+		`<div style="position:absolute;left:0px;top:0px">` + body + `</div></body></html>`
+	p, err := content.ParseString(html)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// summary returns the changes without their blocks on one line each
+func summary(changes []content.Change) []string {
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, fmt.Sprintf("%s %s %s %q %q", c.Kind, c.Element, c.Target, c.Old, c.New))
+	}
+	return lines
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          []string
+	}{
+		{
+			name:   "unchanged",
+			before: `<p data-id="a">one</p><p>two</p>`,
+			after:  `<p data-id="a">one</p><p>two  </p>`,
+		},
+		{
+			name:   "modified",
+			before: `<p data-id="a">one</p>`,
+			after:  `<p data-id="a">one <b>more</b></p>`,
+			want:   []string{`modified paragraph #a ["one"] ["one more"]`},
+		},
+		{
+			name:   "by text",
+			before: `<p>x</p><p>y</p><h1>z</h1>`,
+			after:  `<p>w</p><p>x</p><p>z</p>`,
+			want: []string{
+				`added paragraph  [] ["w"]`,
+				`added paragraph  [] ["z"]`,
+				`removed paragraph  ["y"] []`,
+				`removed heading  ["z"] []`,
+			},
+		},
+		{
+			name:   "removed in place",
+			before: `<p data-id="a">a</p><p data-id="b">b</p><p data-id="c">c</p>`,
+			after:  `<p data-id="a">a</p><p data-id="c">c</p><p>d</p>`,
+			want: []string{
+				`removed paragraph #b ["b"] []`,
+				`added paragraph  [] ["d"]`,
+			},
+		},
+		{
+			name:   "moved",
+			before: `<p data-id="a">a</p><p data-id="b">b</p>`,
+			after:  `<p data-id="b">b</p><p data-id="a">a</p>`,
+		},
+		{
+			name:   "checked",
+			before: `<p data-id="t" data-tag="to-do">buy</p><p data-id="u" data-tag="to-do:completed">call</p>`,
+			after:  `<p data-id="t" data-tag="to-do:completed">buy</p><p data-id="u" data-tag="to-do">call</p>`,
+			want: []string{
+				`checked paragraph #t ["buy"] ["buy"]`,
+				`unchecked paragraph #u ["call"] ["call"]`,
+			},
+		},
+		{
+			name:   "tagged",
+			before: `<p data-id="t">buy</p>`,
+			after:  `<p data-id="t" data-tag="to-do">buy</p>`,
+			want:   []string{`modified paragraph #t ["buy"] ["buy"]`},
+		},
+		{
+			name:   "list item",
+			before: `<ul><li data-id="l">milk</li><li>eggs</li></ul>`,
+			after:  `<ul><li data-id="l">oat milk</li><li>eggs</li></ul>`,
+			want:   []string{`modified list item #l ["milk"] ["oat milk"]`},
+		},
+		{
+			name:   "table",
+			before: `<table data-id="t"><tr><td>a</td><td>b</td></tr></table>`,
+			after:  `<table data-id="t"><tr><td>a</td><td><p>c</p><p>d</p></td></tr></table>`,
+			want:   []string{`modified table #t ["| a | b |"] ["| a | c d |"]`},
+		},
+		{
+			name:   "image",
+			before: `<img data-id="i" alt="chart" src="https://x/a.png" />`,
+			after:  `<img data-id="i" alt="chart" src="https://x/a.png" width="10" height="20" />`,
+			want:   []string{`modified image #i ["![chart](https://x/a.png)"] ["![chart](https://x/a.png) 10x20"]`},
+		},
+		{
+			name:   "duplicate data-id",
+			before: `<p data-id="d">a</p><p data-id="d">b</p>`,
+			after:  `<p data-id="d">b</p>`,
+			want:   []string{`removed paragraph #d ["a"] []`},
+		},
+		{
+			name:   "element kind",
+			before: `<p data-id="a">a</p>`,
+			after:  `<h2 data-id="a">a</h2>`,
+			want: []string{
+				`added heading #a [] ["a"]`,
+				`removed paragraph #a ["a"] []`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := content.Diff(parseVersion(t, "Notes", tt.before), parseVersion(t, "Notes", tt.after))
+			if got := summary(d.Changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDiffTitle(t *testing.T) {
+	d := content.Diff(parseVersion(t, "Notes", `<p>a</p>`), parseVersion(t, "Plans", `<p>a</p>`))
+	want := []string{`modified title  ["Notes"] ["Plans"]`}
+	if got := summary(d.Changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %q, want %q", got, want)
+	}
+	if d.OldTitle != "Notes" || d.NewTitle != "Plans" {
+		t.Errorf("titles = %q, %q", d.OldTitle, d.NewTitle)
+	}
+}
+
+func TestPageDiffWrite(t *testing.T) {
+	d := content.Diff(
+		parseVersion(t, "Notes",
+			`<p data-id="a">one<br />two</p><p data-id="t" data-tag="to-do">buy</p><p>old</p>`),
+		parseVersion(t, "Notes",
+			`<p data-id="a">one<br />three</p><p data-id="t" data-tag="to-do:completed">buy</p><p>new</p>`),
+	)
+
+	var text bytes.Buffer
+	if err := d.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	want := "--- Notes\n+++ Notes\n" +
+		"@@ modified paragraph #a @@\n one\n-two\n+three\n" +
+		"@@ checked paragraph #t @@\n-[ ] buy\n+[x] buy\n" +
+		"@@ added paragraph @@\n+new\n" +
+		"@@ removed paragraph @@\n-old\n"
+	if text.String() != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", text.String(), want)
+	}
+
+	var js bytes.Buffer
+	if err := d.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		OldTitle string
+		Changes  []struct {
+			Kind, Element, Target string
+			Old, New              []string
+		}
+	}
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil {
+		t.Fatalf("WriteJSON() = %s: %v", js.String(), err)
+	}
+	if got.OldTitle != "Notes" || len(got.Changes) != 4 || got.Changes[1].Kind != "checked" || got.Changes[1].Target != "#t" {
+		t.Errorf("WriteJSON() = %s", js.String())
+	}
+}
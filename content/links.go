@@ -0,0 +1,362 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bnixon67/onenote"
+)
+
+// LinkKind is what a link refers to
+type LinkKind string
+
+const (
+	LinkPage     LinkKind = "page"
+	LinkSection  LinkKind = "section"
+	LinkExternal LinkKind = "external"
+)
+
+// Link is a link in the content of a page
+type Link struct {
+	// Href is the URL of the link
+	Href string `json:"href"`
+
+	// Text is the text of the link
+	Text string `json:"text"`
+
+	// Kind is page or section for links to OneNote pages and sections,
+	// with the onenote: client URL or the web URL, and external otherwise
+	Kind LinkKind `json:"kind"`
+
+	// SectionGUID and PageGUID are the OneNote ids in the URL, in lower
+	// case without braces. They are not the ids used by Graph.
+	SectionGUID string `json:"sectionGuid,omitempty"`
+	PageGUID    string `json:"pageGuid,omitempty"`
+
+	// TargetID is the Graph id of the linked page or section, set by
+	// LinkIndex.Resolve if found
+	TargetID string `json:"targetId,omitempty"`
+
+	// Element holds the link
+	Element Block `json:"-"`
+}
+
+var (
+	guidPattern = `\{?([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})\}?`
+
+	// the ids of onenote: client URLs are in the fragment, such as
+	// #Title&section-id={...}&page-id={...}&end
+	sectionIDParam = regexp.MustCompile(`section-id=` + guidPattern)
+	pageIDParam    = regexp.MustCompile(`page-id=` + guidPattern)
+
+	// web URLs have wd=target(Section.one|guid/Title|guid/)
+	targetParam = regexp.MustCompile(`target\((.*)\)`)
+	targetGUID  = regexp.MustCompile(`\|` + guidPattern + `/`)
+)
+
+// webHosts are the hosts of OneNote web URLs, and their subdomains
+var webHosts = []string{
+	"onedrive.live.com", "d.docs.live.net", "onenote.com", "officeapps.live.com",
+	"sharepoint.com", "sharepoint.us", "sharepoint.de", "sharepoint.cn",
+}
+
+// isOneNoteURL reports whether href is a onenote: client URL or a web
+// URL on a OneNote host
+func isOneNoteURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "onenote":
+		return true
+	case "http", "https":
+		host := strings.ToLower(u.Hostname())
+		for _, h := range webHosts {
+			if host == h || strings.HasSuffix(host, "."+h) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseLink returns the Link for a URL, with the kind and the OneNote ids
+// of onenote: client URLs and of web URLs on OneNote hosts. OneNote URLs
+// without section or page ids, such as those of notebooks, and URLs on
+// other hosts are external.
+func ParseLink(href string) Link {
+	l := Link{Href: href, Kind: LinkExternal}
+	if !isOneNoteURL(href) {
+		return l
+	}
+
+	s := href
+	if u, err := url.QueryUnescape(href); err == nil {
+		s = u
+	}
+
+	if m := sectionIDParam.FindStringSubmatch(s); m != nil {
+		l.SectionGUID = strings.ToLower(m[1])
+	}
+	if m := pageIDParam.FindStringSubmatch(s); m != nil {
+		l.PageGUID = strings.ToLower(m[1])
+	}
+	if l.SectionGUID == "" && l.PageGUID == "" {
+		if m := targetParam.FindStringSubmatch(s); m != nil {
+			ids := targetGUID.FindAllStringSubmatch(m[1], 2)
+			if len(ids) > 0 {
+				l.SectionGUID = strings.ToLower(ids[0][1])
+			}
+			if len(ids) > 1 {
+				l.PageGUID = strings.ToLower(ids[1][1])
+			}
+		}
+	}
+
+	switch {
+	case l.PageGUID != "":
+		l.Kind = LinkPage
+	case l.SectionGUID != "":
+		l.Kind = LinkSection
+	}
+	return l
+}
+
+// Links returns the links in the text of the page in document order,
+// consecutive runs with the same URL are one link
+func (p *Page) Links() []Link {
+	var links []Link
+	Walk(p.Blocks, func(b Block) bool {
+		var runs []Run
+		switch t := b.(type) {
+		case *Paragraph:
+			runs = t.Runs
+		case *Heading:
+			runs = t.Runs
+		case *ListItem:
+			runs = t.Runs
+		}
+
+		for i := 0; i < len(runs); {
+			href := runs[i].Href
+			j := i + 1
+			for j < len(runs) && runs[j].Href == href {
+				j++
+			}
+			if href != "" {
+				l := ParseLink(href)
+				l.Text = strings.TrimSpace(RunsText(runs[i:j]))
+				l.Element = b
+				links = append(links, l)
+			}
+			i = j
+		}
+		return true
+	})
+	return links
+}
+
+// LinkIndex finds the pages and sections that links refer to by the ids
+// in the Links of the pages and sections
+type LinkIndex struct {
+	pages    map[string]onenote.Page
+	sections map[string]onenote.Section
+}
+
+// NewLinkIndex returns a LinkIndex for the pages and sections
+func NewLinkIndex(pages []onenote.Page, sections []onenote.Section) *LinkIndex {
+	x := &LinkIndex{
+		pages:    make(map[string]onenote.Page),
+		sections: make(map[string]onenote.Section),
+	}
+
+	for _, p := range pages {
+		for _, href := range []string{p.Links.OneNoteClientUrl.Href, p.Links.OneNoteWebUrl.Href} {
+			if l := ParseLink(href); l.PageGUID != "" {
+				x.pages[l.PageGUID] = p
+			}
+		}
+	}
+	for _, s := range sections {
+		for _, href := range []string{s.Links.OneNoteClientUrl.Href, s.Links.OneNoteWebUrl.Href} {
+			if l := ParseLink(href); l.SectionGUID != "" {
+				x.sections[l.SectionGUID] = s
+			}
+		}
+	}
+
+	return x
+}
+
+// Resolve sets the TargetID of a link to a page or section in the index
+// and reports whether it was found. A link to a page that is not in the
+// index, such as a deleted page, resolves to its section if that is in
+// the index, and its Kind becomes section.
+func (x *LinkIndex) Resolve(l *Link) bool {
+	if l.Kind == LinkPage {
+		if p, ok := x.pages[l.PageGUID]; ok {
+			l.TargetID = p.Id
+			return true
+		}
+	}
+	if l.Kind == LinkPage || l.Kind == LinkSection {
+		if s, ok := x.sections[l.SectionGUID]; ok {
+			l.TargetID = s.Id
+			l.Kind = LinkSection
+			return true
+		}
+	}
+	return false
+}
+
+// LinkGraph holds the links of the pages of a notebook to find the pages
+// that refer to a page, it is not safe for concurrent use
+type LinkGraph struct {
+	index    *LinkIndex
+	pages    []onenote.Page
+	sections []onenote.Section
+	links    map[string][]Link
+}
+
+// NewLinkGraph returns a LinkGraph for the pages and sections, such as
+// those of a notebook. Add the content of each page to it.
+func NewLinkGraph(pages []onenote.Page, sections []onenote.Section) *LinkGraph {
+	return &LinkGraph{
+		index:    NewLinkIndex(pages, sections),
+		pages:    pages,
+		sections: sections,
+		links:    make(map[string][]Link),
+	}
+}
+
+// Add resolves and keeps the links in the content of the page
+func (g *LinkGraph) Add(page onenote.Page, content *Page) {
+	links := content.Links()
+	for i := range links {
+		g.index.Resolve(&links[i])
+	}
+	g.links[page.Id] = links
+}
+
+// Links returns the links of the page with the given id
+func (g *LinkGraph) Links(id string) []Link {
+	return g.links[id]
+}
+
+// Backlinks returns the ids of the pages that link to the page or
+// section with the given id, sorted
+func (g *LinkGraph) Backlinks(id string) []string {
+	return g.backlinks()[id]
+}
+
+// backlinks returns the sorted ids of the pages linking to each page or
+// section by its id
+func (g *LinkGraph) backlinks() map[string][]string {
+	to := make(map[string][]string)
+	for from, links := range g.links {
+		seen := make(map[string]bool)
+		for _, l := range links {
+			if l.TargetID != "" && !seen[l.TargetID] {
+				seen[l.TargetID] = true
+				to[l.TargetID] = append(to[l.TargetID], from)
+			}
+		}
+	}
+	for _, from := range to {
+		sort.Strings(from)
+	}
+	return to
+}
+
+// linkNode is a page or section in the JSON of a LinkGraph
+type linkNode struct {
+	Id        string   `json:"id"`
+	Kind      LinkKind `json:"kind"`
+	Title     string   `json:"title"`
+	Links     []Link   `json:"links,omitempty"`
+	Backlinks []string `json:"backlinks,omitempty"`
+}
+
+// nodes returns the pages and the linked sections sorted by title
+func (g *LinkGraph) nodes() []linkNode {
+	backlinks := g.backlinks()
+
+	var nodes []linkNode
+	for _, p := range g.pages {
+		nodes = append(nodes, linkNode{
+			Id:        p.Id,
+			Kind:      LinkPage,
+			Title:     p.Title,
+			Links:     g.links[p.Id],
+			Backlinks: backlinks[p.Id],
+		})
+	}
+	for _, s := range g.sections {
+		if len(backlinks[s.Id]) > 0 {
+			nodes = append(nodes, linkNode{
+				Id:        s.Id,
+				Kind:      LinkSection,
+				Title:     s.DisplayName,
+				Backlinks: backlinks[s.Id],
+			})
+		}
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Title != nodes[j].Title {
+			return nodes[i].Title < nodes[j].Title
+		}
+		return nodes[i].Id < nodes[j].Id
+	})
+	return nodes
+}
+
+// WriteJSON writes the pages, and the sections that are linked to, with
+// their links and the ids of the pages linking to them
+func (g *LinkGraph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g.nodes())
+}
+
+// WriteDOT writes the links between pages and sections as a GraphViz
+// digraph, external links and links not found are left out
+func (g *LinkGraph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph links {\n")
+
+	nodes := g.nodes()
+	for _, n := range nodes {
+		shape := "box"
+		if n.Kind == LinkSection {
+			shape = "folder"
+		}
+		fmt.Fprintf(&b, "\t%s [label=%s shape=%s];\n",
+			strconv.Quote(n.Id), strconv.Quote(n.Title), shape)
+	}
+
+	for _, n := range nodes {
+		seen := make(map[string]bool)
+		for _, l := range n.Links {
+			if l.TargetID == "" || seen[l.TargetID] {
+				continue
+			}
+			seen[l.TargetID] = true
+			fmt.Fprintf(&b, "\t%s -> %s;\n", strconv.Quote(n.Id), strconv.Quote(l.TargetID))
+		}
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
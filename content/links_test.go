@@ -0,0 +1,128 @@
+package content_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/content"
+)
+
+const (
+	sectionGUID = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	pageGUID    = "5e6f7a8b-9c0d-4e1f-a2b3-c4d5e6f7a8b9"
+	otherGUID   = "11111111-2222-4333-8444-555555555555"
+)
+
+// clientURL returns a onenote: URL for the section and page
+func clientURL(section, page string) string {
+	u := "onenote:https://d.docs.live.net/abc/Documents/Work/Notes.one#Plan&section-id={" + strings.ToUpper(section) + "}"
+	if page != "" {
+		u += "&page-id={" + page + "}"
+	}
+	return u + "&end"
+}
+
+// webURL returns a SharePoint web URL for the section and page
+func webURL(host, section, page string) string {
+	return "https://" + host + "/personal/a/_layouts/15/Doc.aspx?sourcedoc=x&wd=target%28Notes.one%7C" +
+		section + "%2FPlan%7C" + page + "%2F%29"
+}
+
+func TestParseLink(t *testing.T) {
+	tests := []struct {
+		name        string
+		href        string
+		wantKind    content.LinkKind
+		wantSection string
+		wantPage    string
+	}{
+		{"client page", clientURL(sectionGUID, pageGUID), content.LinkPage, sectionGUID, pageGUID},
+		{"client section", clientURL(sectionGUID, ""), content.LinkSection, sectionGUID, ""},
+		{"web page", webURL("contoso-my.sharepoint.com", sectionGUID, pageGUID), content.LinkPage, sectionGUID, pageGUID},
+		{"web upper case host", webURL("Contoso.SharePoint.com", sectionGUID, pageGUID), content.LinkPage, sectionGUID, pageGUID},
+		{"onedrive", "https://onedrive.live.com/edit.aspx?resid=1&wd=target%28Notes.one%7C" + sectionGUID + "%2F%29", content.LinkSection, sectionGUID, ""},
+		{"notebook", "onenote:https://d.docs.live.net/abc/Documents/Work/", content.LinkExternal, "", ""},
+		{"other host", webURL("example.com", sectionGUID, pageGUID), content.LinkExternal, "", ""},
+		{"lookalike host", webURL("sharepoint.com.example.com", sectionGUID, pageGUID), content.LinkExternal, "", ""},
+		{"ids in other url", "https://example.com/?section-id={" + sectionGUID + "}&page-id={" + pageGUID + "}", content.LinkExternal, "", ""},
+		{"mail", "mailto:a@example.com", content.LinkExternal, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := content.ParseLink(tt.href)
+			if l.Kind != tt.wantKind || l.SectionGUID != tt.wantSection || l.PageGUID != tt.wantPage {
+				t.Errorf("ParseLink() = %s %q %q, want %s %q %q",
+					l.Kind, l.SectionGUID, l.PageGUID, tt.wantKind, tt.wantSection, tt.wantPage)
+			}
+		})
+	}
+}
+
+func TestLinkGraph(t *testing.T) {
+	var plan, notes onenote.Page
+	plan.Id, plan.Title = "page-plan", "Plan"
+	plan.Links.OneNoteClientUrl.Href = clientURL(sectionGUID, pageGUID)
+	notes.Id, notes.Title = "page-notes", "Notes"
+	notes.Links.OneNoteWebUrl.Href = webURL("contoso.sharepoint.com", sectionGUID, otherGUID)
+
+	var section onenote.Section
+	section.Id, section.DisplayName = "section-work", "Work"
+	section.Links.OneNoteClientUrl.Href = clientURL(sectionGUID, "")
+
+	graph := content.NewLinkGraph([]onenote.Page{plan, notes}, []onenote.Section{section})
+
+	deleted := "33333333-2222-4333-8444-555555555555"
+	for _, add := range []struct {
+		page onenote.Page
+		body string
+	}{
+		{plan, `<p><a href="` + webURL("contoso.sharepoint.com", sectionGUID, otherGUID) + `">see notes</a></p>`},
+		{notes, `<p><a href="` + clientURL(sectionGUID, pageGUID) + `">plan</a> and ` +
+			`<a href="` + clientURL(sectionGUID, deleted) + `">deleted</a> ` +
+			`<a href="https://example.com">site</a></p>`},
+	} {
+		p, err := content.ParseString(page + add.body + `</body></html>`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		graph.Add(add.page, p)
+	}
+
+	tests := []struct {
+		id   string
+		want []string
+	}{
+		{plan.Id, []string{notes.Id}},
+		{notes.Id, []string{plan.Id}},
+		{section.Id, []string{notes.Id}},
+	}
+	for _, tt := range tests {
+		if got := graph.Backlinks(tt.id); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Backlinks(%s) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+
+	links := graph.Links(notes.Id)
+	var got []string
+	for _, l := range links {
+		got = append(got, string(l.Kind)+" "+l.Text+" "+l.TargetID)
+	}
+	want := []string{"page plan page-plan", "section deleted section-work", "external site "}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Links() = %q, want %q", got, want)
+	}
+
+	var dot bytes.Buffer
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, edge := range []string{`"page-notes" -> "page-plan"`, `"page-notes" -> "section-work"`, `"page-plan" -> "page-notes"`} {
+		if !strings.Contains(dot.String(), edge) {
+			t.Errorf("DOT %s does not contain %s", dot.String(), edge)
+		}
+	}
+}
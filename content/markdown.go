@@ -0,0 +1,420 @@
+package content
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MarkdownOptions change how a page is converted to Markdown
+type MarkdownOptions struct {
+	// Resource returns the link for the URL of an image or attachment,
+	// such as the relative path of a downloaded copy. The URL is used
+	// if Resource is nil or returns an empty string.
+	Resource func(url string) string
+
+	// Meta is added to the front matter after the title and created
+	// time, such as the page id or section
+	Meta map[string]string
+
+	// NoFrontMatter leaves out the YAML front matter
+	NoFrontMatter bool
+}
+
+// Markdown returns the page as GitHub Flavored Markdown with the title
+// and metadata as YAML front matter. Outlines, images and attachments
+// placed on the page are merged in reading order, top to bottom and
+// then left to right.
+func (p *Page) Markdown(opts *MarkdownOptions) string {
+	if opts == nil {
+		opts = &MarkdownOptions{}
+	}
+	m := &markdown{opts: opts}
+
+	var b strings.Builder
+	if !opts.NoFrontMatter {
+		b.WriteString(m.frontMatter(p))
+	}
+
+	var blocks []Block
+	for _, block := range ReadingOrder(p.Blocks) {
+		// the content of outlines is merged
+		if o, ok := block.(*Outline); ok {
+			blocks = append(blocks, o.Blocks...)
+		} else {
+			blocks = append(blocks, block)
+		}
+	}
+	b.WriteString(m.blocks(blocks))
+
+	return b.String()
+}
+
+// Resources returns the URLs of the images and attachments of the page,
+// the full resolution version of images where available
+func (p *Page) Resources() []string {
+	var urls []string
+	Walk(p.Blocks, func(b Block) bool {
+		switch t := b.(type) {
+		case *Image:
+			if src := imageSource(t); src != "" {
+				urls = append(urls, src)
+			}
+		case *Object:
+			if t.Data != "" {
+				urls = append(urls, t.Data)
+			}
+		}
+		return true
+	})
+	return urls
+}
+
+// imageSource returns the full resolution URL of an image if available
+func imageSource(img *Image) string {
+	if img.FullResSrc != "" {
+		return img.FullResSrc
+	}
+	return img.Src
+}
+
+// ReadingOrder returns the blocks sorted top to bottom and then left to
+// right by position, blocks without a position keep their place
+// relative to each other after the positioned blocks
+func ReadingOrder(blocks []Block) []Block {
+	sorted := make([]Block, len(blocks))
+	copy(sorted, blocks)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aok := blockPosition(sorted[i])
+		b, bok := blockPosition(sorted[j])
+		switch {
+		case !aok || !bok:
+			return aok && !bok
+		case a.Top != b.Top:
+			return a.Top < b.Top
+		}
+		return a.Left < b.Left
+	})
+
+	return sorted
+}
+
+// blockPosition returns the position of a block placed on the page
+func blockPosition(b Block) (Position, bool) {
+	switch t := b.(type) {
+	case *Outline:
+		return t.Position, true
+	case *Image:
+		if t.Position != nil {
+			return *t.Position, true
+		}
+	case *Object:
+		if t.Position != nil {
+			return *t.Position, true
+		}
+	}
+	return Position{}, false
+}
+
+// markdown converts blocks with the options
+type markdown struct {
+	opts *MarkdownOptions
+}
+
+// frontMatter returns the YAML front matter for the page
+func (m *markdown) frontMatter(p *Page) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("title: " + strconv.Quote(p.Title) + "\n")
+	if p.Created != "" {
+		b.WriteString("created: " + strconv.Quote(p.Created) + "\n")
+	}
+
+	keys := make([]string, 0, len(m.opts.Meta))
+	for k := range m.opts.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(k + ": " + strconv.Quote(m.opts.Meta[k]) + "\n")
+	}
+
+	b.WriteString("---\n\n")
+	return b.String()
+}
+
+// resource returns the link for a resource URL
+func (m *markdown) resource(url string) string {
+	if m.opts.Resource != nil {
+		if link := m.opts.Resource(url); link != "" {
+			return link
+		}
+	}
+	return url
+}
+
+// blocks returns the Markdown for blocks separated by blank lines
+func (m *markdown) blocks(blocks []Block) string {
+	var parts []string
+	for _, b := range blocks {
+		if s := m.block(b); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "\n\n") + "\n"
+}
+
+// block returns the Markdown for one block without a trailing newline
+func (m *markdown) block(b Block) string {
+	switch t := b.(type) {
+	case *Outline:
+		return strings.TrimSuffix(m.blocks(t.Blocks), "\n")
+
+	case *Paragraph:
+		text := m.inline(t.Runs)
+		if box, ok := checkbox(&t.Attrs); ok {
+			return "- " + box + text
+		}
+		return text
+
+	case *Heading:
+		return strings.Repeat("#", t.Level) + " " + m.inline(t.Runs)
+
+	case *List:
+		return m.list(t)
+
+	case *Table:
+		return m.table(t)
+
+	case *Image:
+		return "![" + escapeMarkdown(t.Alt) + "](" + linkDestination(m.resource(imageSource(t))) + ")"
+
+	case *Object:
+		name := t.Name
+		if name == "" {
+			name = "attachment"
+		}
+		return "[" + escapeMarkdown(name) + "](" + linkDestination(m.resource(t.Data)) + ")"
+
+	case *Raw:
+		return t.HTML
+	}
+	return ""
+}
+
+// checkbox returns the task list marker for a to-do style tag
+func checkbox(a *Attrs) (string, bool) {
+	for _, tag := range a.Tags {
+		if strings.HasPrefix(tag.Name, "to-do") {
+			if tag.Completed {
+				return "[x] ", true
+			}
+			return "[ ] ", true
+		}
+	}
+	return "", false
+}
+
+// list returns the Markdown for a list with nested blocks indented
+// below their item
+func (m *markdown) list(l *List) string {
+	var lines []string
+	for i, item := range l.Items {
+		marker := "- "
+		if l.Ordered {
+			marker = strconv.Itoa(i+1) + ". "
+		}
+		if box, ok := checkbox(&item.Attrs); ok {
+			marker += box
+		}
+
+		lines = append(lines, marker+m.inline(item.Runs))
+
+		if nested := strings.TrimSuffix(m.blocks(item.Blocks), "\n"); nested != "" {
+			indent := strings.Repeat(" ", len(marker))
+			if strings.HasSuffix(marker, "] ") {
+				indent = strings.Repeat(" ", len(marker)-4)
+			}
+			for _, line := range strings.Split(nested, "\n") {
+				if line == "" {
+					lines = append(lines, "")
+				} else {
+					lines = append(lines, indent+line)
+				}
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// table returns a GFM table, the first row is the header. A cell
+// spanning several columns or rows is repeated in each of them as in
+// Grid, since GFM has no spans.
+func (m *markdown) table(t *Table) string {
+	grid := t.grid(m.cell)
+	if len(grid) == 0 || len(grid[0]) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i, cells := range grid {
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+
+		if i == 0 {
+			sep := make([]string, len(cells))
+			for j := range sep {
+				sep[j] = "---"
+			}
+			lines = append(lines, "| "+strings.Join(sep, " | ")+" |")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cell returns the content of a table cell on one line
+func (m *markdown) cell(c *Cell) string {
+	var parts []string
+	for _, b := range c.Blocks {
+		var s string
+		switch t := b.(type) {
+		case *List:
+			// lists cannot be nested in GFM tables
+			for _, item := range t.Items {
+				parts = append(parts, "• "+m.inline(item.Runs))
+			}
+			continue
+		case *Table:
+			s = escapeMarkdown(Text(t))
+		default:
+			s = m.block(b)
+		}
+		if s != "" {
+			parts = append(parts, strings.ReplaceAll(s, "\n", "<br>"))
+		}
+	}
+	return strings.Join(parts, "<br>")
+}
+
+// inline returns the Markdown for runs, consecutive runs with the same
+// link become one link
+func (m *markdown) inline(runs []Run) string {
+	var b strings.Builder
+
+	for i := 0; i < len(runs); {
+		if runs[i].Break {
+			b.WriteString("\\\n")
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(runs) && !runs[j].Break && runs[j].Href == runs[i].Href {
+			j++
+		}
+
+		var text strings.Builder
+		for _, r := range runs[i:j] {
+			text.WriteString(formatRun(r))
+		}
+
+		if href := runs[i].Href; href != "" {
+			b.WriteString("[" + text.String() + "](" + linkDestination(href) + ")")
+		} else {
+			b.WriteString(text.String())
+		}
+		i = j
+	}
+
+	return b.String()
+}
+
+// formatRun returns the Markdown for the text of a run with its
+// formatting, spaces are kept outside the markers
+func formatRun(r Run) string {
+	trimmed := strings.TrimSpace(r.Text)
+	if trimmed == "" {
+		return r.Text
+	}
+	lead := r.Text[:strings.Index(r.Text, trimmed)]
+	trail := r.Text[len(lead)+len(trimmed):]
+
+	var text string
+	if r.Code {
+		text = codeSpan(trimmed)
+	} else {
+		text = escapeMarkdown(trimmed)
+	}
+
+	// there is no Markdown for these, use HTML
+	if r.Underline {
+		text = "<u>" + text + "</u>"
+	}
+	if r.Superscript {
+		text = "<sup>" + text + "</sup>"
+	}
+	if r.Subscript {
+		text = "<sub>" + text + "</sub>"
+	}
+
+	if r.Strike {
+		text = "~~" + text + "~~"
+	}
+	if r.Italic {
+		text = "*" + text + "*"
+	}
+	if r.Bold {
+		text = "**" + text + "**"
+	}
+
+	return lead + text + trail
+}
+
+// codeSpan returns text as inline code, with a fence longer than any
+// backticks in text
+func codeSpan(text string) string {
+	fence := "`"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// markdownEscaper escapes the characters with meaning in Markdown text
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "|", `\|`, "#", `\#`, "~", `\~`,
+)
+
+// orderedMarker matches the number of an ordered list item
+var orderedMarker = regexp.MustCompile(`^[0-9]+[.)]`)
+
+// escapeMarkdown escapes text so it is not read as Markdown, including
+// a list marker at its start
+func escapeMarkdown(text string) string {
+	text = markdownEscaper.Replace(text)
+	switch {
+	case strings.HasPrefix(text, "-"), strings.HasPrefix(text, "+"):
+		return `\` + text
+	case orderedMarker.MatchString(text):
+		n := len(orderedMarker.FindString(text)) - 1
+		return text[:n] + `\` + text[n:]
+	}
+	return text
+}
+
+// linkDestination returns url for a link, in angle brackets if it has
+// spaces or parentheses
+func linkDestination(url string) string {
+	if strings.ContainsAny(url, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(url) + ">"
+	}
+	return url
+}
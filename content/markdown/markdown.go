@@ -0,0 +1,567 @@
+// Package markdown converts Markdown to the HTML to create a OneNote
+// page, with local images as parts to send with it.
+//
+//	html, parts, err := markdown.Convert(source, &markdown.Options{Dir: dir})
+//	...
+//	page, err := client.CreatePage(ctx, sectionID, html, parts...)
+package markdown
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bnixon67/onenote"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	extast "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// ErrOutsideDir is returned by Convert for a local image outside
+// Options.Dir, use errors.Is to check for it
+var ErrOutsideDir = errors.New("markdown: image outside the directory")
+
+// Options change how Markdown is converted to page HTML
+type Options struct {
+	// Title of the page, if empty a level 1 heading at the start of the
+	// Markdown is the title
+	Title string
+
+	// Created time of the page, the time of creation if zero
+	Created time.Time
+
+	// Dir is the directory of local images, the current directory if
+	// empty. Images are only read from within Dir, a path that is
+	// absolute, a file URL, has .. elements or a symbolic link leaving
+	// Dir is an error. Symbolic links are followed if relative and
+	// within Dir.
+	Dir string
+
+	// AnyDir allows local images outside Dir, for Markdown from a
+	// trusted source
+	AnyDir bool
+
+	// ReadFile returns the content of a local image, which is read
+	// through an os.Root for Dir if nil
+	ReadFile func(name string) ([]byte, error)
+
+	// RawHTML keeps HTML in the Markdown, it is escaped as text otherwise
+	RawHTML bool
+}
+
+// Convert returns the HTML to create a page from CommonMark with the
+// GitHub Flavored Markdown tables, task lists, strikethrough and
+// autolinks. Task list items become to-do tags. Local images are
+// returned as parts to send with the HTML, see onenote.Client.CreatePage.
+//
+// Only elements that OneNote accepts are used: code is set in Consolas,
+// block quotes are indented and thematic breaks are left out.
+func Convert(source []byte, opts *Options) (string, []onenote.Part, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).
+		Parser().Parse(text.NewReader(source))
+
+	c := &converter{source: source, opts: opts, images: make(map[string]string)}
+	defer c.close()
+
+	title := opts.Title
+	if h, ok := doc.FirstChild().(*ast.Heading); ok && h.Level == 1 && title == "" {
+		title = c.plain(h)
+		doc.RemoveChild(doc, h)
+	}
+
+	c.b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	c.b.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	if !opts.Created.IsZero() {
+		c.b.WriteString(`<meta name="created" content="` + opts.Created.Format(time.RFC3339) + `" />` + "\n")
+	}
+	c.b.WriteString("</head>\n<body>\n")
+
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		if err := c.block(n); err != nil {
+			return "", nil, err
+		}
+	}
+
+	c.b.WriteString("</body>\n</html>\n")
+
+	return c.b.String(), c.parts, nil
+}
+
+// converter writes the HTML for a Markdown document
+type converter struct {
+	source []byte
+	opts   *Options
+	b      strings.Builder
+
+	// parts are the local images, images maps their path to the part name
+	parts  []onenote.Part
+	images map[string]string
+
+	// root is Dir, opened for the first local image
+	root *os.Root
+}
+
+// close closes root if opened
+func (c *converter) close() {
+	if c.root != nil {
+		c.root.Close()
+	}
+}
+
+// block writes the HTML for a block node
+func (c *converter) block(n ast.Node) error {
+	switch t := n.(type) {
+	case *ast.Paragraph, *ast.TextBlock:
+		return c.paragraph(n)
+
+	case *ast.Heading:
+		tag := "h" + strconv.Itoa(t.Level)
+		c.b.WriteString("<" + tag + ">")
+		if err := c.inlines(n); err != nil {
+			return err
+		}
+		c.b.WriteString("</" + tag + ">\n")
+
+	case *ast.List:
+		return c.list(t)
+
+	case *extast.Table:
+		return c.table(t)
+
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		c.b.WriteString("<pre>")
+		c.b.WriteString(html.EscapeString(strings.TrimSuffix(c.lines(n), "\n")))
+		c.b.WriteString("</pre>\n")
+
+	case *ast.Blockquote:
+		// blockquote is not supported, indent the content instead
+		c.b.WriteString(`<div style="margin-left:36px">` + "\n")
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			if err := c.block(child); err != nil {
+				return err
+			}
+		}
+		c.b.WriteString("</div>\n")
+
+	case *ast.HTMLBlock:
+		raw := c.lines(n)
+		if t.HasClosure() {
+			raw += string(t.ClosureLine.Value(c.source))
+		}
+		if c.opts.RawHTML {
+			c.b.WriteString(raw)
+		} else {
+			c.b.WriteString("<p>" + html.EscapeString(strings.TrimSpace(raw)) + "</p>\n")
+		}
+
+	case *ast.ThematicBreak:
+		// there is no horizontal rule in OneNote
+	}
+	return nil
+}
+
+// paragraph writes a p element, images are placed between paragraphs
+// as OneNote does not keep them within the text
+func (c *converter) paragraph(n ast.Node) error {
+	open := false
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if img, ok := child.(*ast.Image); ok {
+			if open {
+				c.b.WriteString("</p>\n")
+				open = false
+			}
+			if err := c.image(img); err != nil {
+				return err
+			}
+			c.b.WriteString("\n")
+			continue
+		}
+
+		if !open {
+			// skip the space between images
+			if t, ok := child.(*ast.Text); ok && !t.HardLineBreak() &&
+				len(bytes.TrimSpace(t.Value(c.source))) == 0 {
+				continue
+			}
+			c.b.WriteString("<p>")
+			open = true
+		}
+		if err := c.inline(child); err != nil {
+			return err
+		}
+	}
+	if open {
+		c.b.WriteString("</p>\n")
+	}
+	return nil
+}
+
+// list writes a list, task list items have the to-do tag
+func (c *converter) list(l *ast.List) error {
+	tag := "ul"
+	if l.IsOrdered() {
+		tag = "ol"
+	}
+	c.b.WriteString("<" + tag + ">\n")
+
+	for item := l.FirstChild(); item != nil; item = item.NextSibling() {
+		c.b.WriteString("<li")
+		if box := taskCheckBox(item); box != nil {
+			if box.IsChecked {
+				c.b.WriteString(` data-tag="to-do:completed"`)
+			} else {
+				c.b.WriteString(` data-tag="to-do"`)
+			}
+		}
+		c.b.WriteString(">")
+
+		// the paragraphs are the text of the item, separated by line
+		// breaks, other blocks such as nested lists follow them
+		first := true
+		for child := item.FirstChild(); child != nil; child = child.NextSibling() {
+			switch child.(type) {
+			case *ast.Paragraph, *ast.TextBlock:
+				if !first {
+					c.b.WriteString("<br />")
+				}
+				if err := c.inlines(child); err != nil {
+					return err
+				}
+				first = false
+			default:
+				c.b.WriteString("\n")
+				if err := c.block(child); err != nil {
+					return err
+				}
+			}
+		}
+
+		c.b.WriteString("</li>\n")
+	}
+
+	c.b.WriteString("</" + tag + ">\n")
+	return nil
+}
+
+// taskCheckBox returns the check box of a task list item, nil if the
+// item is not a task
+func taskCheckBox(item ast.Node) *extast.TaskCheckBox {
+	if first := item.FirstChild(); first != nil {
+		if box, ok := first.FirstChild().(*extast.TaskCheckBox); ok {
+			return box
+		}
+	}
+	return nil
+}
+
+// table writes a table with a border, the header cells are bold
+func (c *converter) table(t *extast.Table) error {
+	c.b.WriteString(`<table border="1">` + "\n")
+
+	for row := t.FirstChild(); row != nil; row = row.NextSibling() {
+		_, header := row.(*extast.TableHeader)
+		c.b.WriteString("<tr>")
+
+		for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+			c.b.WriteString("<td")
+			if tc, ok := cell.(*extast.TableCell); ok {
+				switch tc.Alignment {
+				case extast.AlignCenter:
+					c.b.WriteString(` style="text-align:center"`)
+				case extast.AlignRight:
+					c.b.WriteString(` style="text-align:right"`)
+				}
+			}
+			c.b.WriteString(">")
+
+			if header {
+				c.b.WriteString("<b>")
+			}
+			if err := c.inlines(cell); err != nil {
+				return err
+			}
+			if header {
+				c.b.WriteString("</b>")
+			}
+
+			c.b.WriteString("</td>")
+		}
+
+		c.b.WriteString("</tr>\n")
+	}
+
+	c.b.WriteString("</table>\n")
+	return nil
+}
+
+// inlines writes the HTML for the inline children of n
+func (c *converter) inlines(n ast.Node) error {
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if err := c.inline(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inline writes the HTML for an inline node
+func (c *converter) inline(n ast.Node) error {
+	switch t := n.(type) {
+	case *ast.Text:
+		c.b.WriteString(html.EscapeString(string(t.Value(c.source))))
+		switch {
+		case t.HardLineBreak():
+			c.b.WriteString("<br />")
+		case t.SoftLineBreak():
+			c.b.WriteString(" ")
+		}
+
+	case *ast.String:
+		if t.IsRaw() {
+			c.b.Write(t.Value)
+		} else {
+			c.b.WriteString(html.EscapeString(string(t.Value)))
+		}
+
+	case *ast.CodeSpan:
+		var code bytes.Buffer
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			switch s := child.(type) {
+			case *ast.Text:
+				code.Write(s.Value(c.source))
+			case *ast.String:
+				code.Write(s.Value)
+			}
+		}
+		c.b.WriteString(`<span style="font-family:Consolas">` + html.EscapeString(code.String()) + "</span>")
+
+	case *ast.Emphasis:
+		tag := "i"
+		if t.Level == 2 {
+			tag = "b"
+		}
+		c.b.WriteString("<" + tag + ">")
+		if err := c.inlines(n); err != nil {
+			return err
+		}
+		c.b.WriteString("</" + tag + ">")
+
+	case *extast.Strikethrough:
+		c.b.WriteString("<del>")
+		if err := c.inlines(n); err != nil {
+			return err
+		}
+		c.b.WriteString("</del>")
+
+	case *ast.Link:
+		c.b.WriteString(`<a href="` + html.EscapeString(string(t.Destination)) + `">`)
+		if err := c.inlines(n); err != nil {
+			return err
+		}
+		c.b.WriteString("</a>")
+
+	case *ast.AutoLink:
+		href := string(t.URL(c.source))
+		if t.AutoLinkType == ast.AutoLinkEmail && !strings.HasPrefix(strings.ToLower(href), "mailto:") {
+			href = "mailto:" + href
+		}
+		c.b.WriteString(`<a href="` + html.EscapeString(href) + `">` +
+			html.EscapeString(string(t.Label(c.source))) + "</a>")
+
+	case *ast.Image:
+		return c.image(t)
+
+	case *ast.RawHTML:
+		var raw bytes.Buffer
+		for i := 0; i < t.Segments.Len(); i++ {
+			seg := t.Segments.At(i)
+			raw.Write(seg.Value(c.source))
+		}
+		if c.opts.RawHTML {
+			c.b.Write(raw.Bytes())
+		} else {
+			c.b.WriteString(html.EscapeString(raw.String()))
+		}
+
+	case *extast.TaskCheckBox:
+		// written as the to-do tag of the list item
+
+	default:
+		return c.inlines(n)
+	}
+	return nil
+}
+
+// image writes an img element, a local image is added as a part
+func (c *converter) image(img *ast.Image) error {
+	dest := string(img.Destination)
+	alt := c.plain(img)
+
+	src := dest
+	if !isRemote(dest) {
+		var err error
+		if src, err = c.localImage(dest); err != nil {
+			return err
+		}
+	}
+
+	c.b.WriteString(`<img src="` + html.EscapeString(src) + `" alt="` + html.EscapeString(alt) + `" />`)
+	return nil
+}
+
+// isRemote reports whether dest is a URL OneNote can get the image from
+func isRemote(dest string) bool {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "data":
+		return true
+	}
+	return false
+}
+
+// localImage reads the image at dest and returns the "name:" reference
+// to its part, an image used more than once is added once
+func (c *converter) localImage(dest string) (string, error) {
+	name := dest
+	if u, err := url.Parse(dest); err == nil && u.Scheme == "file" {
+		name = u.Path
+	} else if p, err := url.PathUnescape(dest); err == nil {
+		name = p
+	}
+	name = filepath.FromSlash(name)
+
+	if !filepath.IsLocal(name) && !c.opts.AnyDir {
+		return "", fmt.Errorf("markdown: image %s: %w", dest, ErrOutsideDir)
+	}
+	rel := name
+	if !filepath.IsAbs(name) && c.opts.Dir != "" {
+		name = filepath.Join(c.opts.Dir, name)
+	}
+
+	if part, ok := c.images[name]; ok {
+		return "name:" + part, nil
+	}
+
+	var data []byte
+	var err error
+	switch {
+	case c.opts.ReadFile != nil:
+		data, err = c.opts.ReadFile(name)
+	case c.opts.AnyDir:
+		data, err = os.ReadFile(name)
+	default:
+		data, err = c.readLocal(rel)
+	}
+	if err != nil {
+		return "", fmt.Errorf("markdown: image %s: %w", dest, err)
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(filepath.ToSlash(name)))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	part := "image" + strconv.Itoa(len(c.parts)+1)
+	c.parts = append(c.parts, onenote.Part{Name: part, ContentType: contentType, Data: data})
+	c.images[name] = part
+
+	return "name:" + part, nil
+}
+
+// readLocal returns the content of the file at name within Dir,
+// following symbolic links only within Dir
+func (c *converter) readLocal(name string) ([]byte, error) {
+	dir := c.opts.Dir
+	if dir == "" {
+		dir = "."
+	}
+
+	if c.root == nil {
+		root, err := os.OpenRoot(dir)
+		if err != nil {
+			return nil, err
+		}
+		c.root = root
+	}
+
+	data, err := c.root.ReadFile(name)
+	if err != nil && outside(dir, filepath.Join(dir, name)) {
+		return nil, ErrOutsideDir
+	}
+	return data, err
+}
+
+// outside reports whether the file at name is outside dir once symbolic
+// links are followed
+func outside(dir, name string) bool {
+	base, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	target, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(base, target)
+	return err == nil && !filepath.IsLocal(rel)
+}
+
+// plain returns the text of the inline children of n without formatting
+func (c *converter) plain(n ast.Node) string {
+	var b strings.Builder
+	var walk func(n ast.Node)
+	walk = func(n ast.Node) {
+		for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+			switch t := child.(type) {
+			case *ast.Text:
+				b.Write(t.Value(c.source))
+				if t.SoftLineBreak() || t.HardLineBreak() {
+					b.WriteByte(' ')
+				}
+			case *ast.String:
+				b.Write(t.Value)
+			case *ast.AutoLink:
+				b.Write(t.Label(c.source))
+			case *ast.RawHTML:
+				for i := 0; i < t.Segments.Len(); i++ {
+					seg := t.Segments.At(i)
+					b.Write(seg.Value(c.source))
+				}
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	return strings.TrimSpace(b.String())
+}
+
+// lines returns the source lines of a block node
+func (c *converter) lines(n ast.Node) string {
+	var b strings.Builder
+	lines := n.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		seg := lines.At(i)
+		b.Write(seg.Value(c.source))
+	}
+	return b.String()
+}
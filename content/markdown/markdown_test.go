@@ -0,0 +1,176 @@
+package markdown_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote/content/markdown"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		opts    *markdown.Options
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "title from heading",
+			source:  "# Plan & goals\n\ntext",
+			want:    []string{"<title>Plan &amp; goals</title>", "<p>text</p>"},
+			notWant: []string{"<h1>"},
+		},
+		{
+			name:   "title option",
+			source: "# Heading\n",
+			opts:   &markdown.Options{Title: "Given"},
+			want:   []string{"<title>Given</title>", "<h1>Heading</h1>"},
+		},
+		{
+			name:   "inline",
+			source: "**b** *i* ~~s~~ `c<d` [l](https://example.com) <https://go.dev>",
+			want: []string{
+				"<b>b</b>", "<i>i</i>", "<del>s</del>",
+				`<span style="font-family:Consolas">c&lt;d</span>`,
+				`<a href="https://example.com">l</a>`, `<a href="https://go.dev">https://go.dev</a>`,
+			},
+		},
+		{
+			name:   "task list",
+			source: "- [ ] open\n- [x] done\n- plain\n",
+			want:   []string{`<li data-tag="to-do">open</li>`, `<li data-tag="to-do:completed">done</li>`, "<li>plain</li>"},
+		},
+		{
+			name:   "table",
+			source: "| a | b |\n|---|--:|\n| 1 | 2 |\n",
+			want:   []string{"<td><b>a</b></td>", `<td style="text-align:right">2</td>`},
+		},
+		{
+			name:    "raw html escaped",
+			source:  "<script>x</script>\n\nand <b>bold</b>",
+			want:    []string{"<p>&lt;script&gt;x&lt;/script&gt;</p>", "and &lt;b&gt;bold&lt;/b&gt;"},
+			notWant: []string{"<script>"},
+		},
+		{
+			name:   "raw html kept",
+			source: "and <b>bold</b>",
+			opts:   &markdown.Options{RawHTML: true},
+			want:   []string{"and <b>bold</b>"},
+		},
+		{
+			name:    "unsupported blocks",
+			source:  "> quoted\n\n---\n\n    code\n",
+			want:    []string{`<div style="margin-left:36px">`, "<pre>code</pre>"},
+			notWant: []string{"<blockquote>", "<hr"},
+		},
+		{
+			name:   "remote image",
+			source: "![chart](https://example.com/c.png)",
+			want:   []string{`<img src="https://example.com/c.png" alt="chart" />`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, parts, err := markdown.Convert([]byte(tt.source), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(parts) != 0 {
+				t.Errorf("got %d parts", len(parts))
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(html, want) {
+					t.Errorf("HTML %q does not contain %q", html, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(html, notWant) {
+					t.Errorf("HTML %q contains %q", html, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertLocalImages(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "notes")
+	for name, data := range map[string]string{
+		filepath.Join(dir, "a.png"):        "\x89PNG a",
+		filepath.Join(dir, "img", "b.png"): "\x89PNG b",
+		filepath.Join(root, "secret.png"):  "\x89PNG secret",
+	} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	secret := filepath.ToSlash(filepath.Join(root, "secret.png"))
+
+	// links to a file outside and to a file inside the directory
+	if err := os.Symlink(filepath.Join(root, "secret.png"), filepath.Join(dir, "link.png")); err != nil {
+		t.Skip("no symbolic links:", err)
+	}
+	if err := os.Symlink("img", filepath.Join(dir, "pictures")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(dir, "up")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		source    string
+		anyDir    bool
+		wantParts []string
+		wantErr   error
+	}{
+		{"relative", "![a](a.png) ![b](img/b.png)", false, []string{"\x89PNG a", "\x89PNG b"}, nil},
+		{"same image once", "![a](a.png)\n\n![again](./a.png)", false, []string{"\x89PNG a"}, nil},
+		{"escaped", "![b](img%2Fb.png)", false, []string{"\x89PNG b"}, nil},
+		{"inner dot dot", "![a](img/../a.png)", false, []string{"\x89PNG a"}, nil},
+		{"parent", "![s](../secret.png)", false, nil, markdown.ErrOutsideDir},
+		{"absolute", "![s](" + secret + ")", false, nil, markdown.ErrOutsideDir},
+		{"file url", "![s](file://" + secret + ")", false, nil, markdown.ErrOutsideDir},
+		{"parent allowed", "![s](../secret.png)", true, []string{"\x89PNG secret"}, nil},
+		{"absolute allowed", "![s](" + secret + ")", true, []string{"\x89PNG secret"}, nil},
+		{"missing", "![m](missing.png)", false, nil, os.ErrNotExist},
+		{"link outside", "![s](link.png)", false, nil, markdown.ErrOutsideDir},
+		{"directory link outside", "![s](up/secret.png)", false, nil, markdown.ErrOutsideDir},
+		{"link inside", "![b](pictures/b.png)", false, []string{"\x89PNG b"}, nil},
+		{"link outside allowed", "![s](link.png)", true, []string{"\x89PNG secret"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html, parts, err := markdown.Convert([]byte(tt.source), &markdown.Options{Dir: dir, AnyDir: tt.anyDir})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Convert() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			var got []string
+			for i, part := range parts {
+				got = append(got, string(part.Data))
+				if !strings.Contains(html, `src="name:`+part.Name+`"`) {
+					t.Errorf("HTML %q does not refer to part %d", html, i)
+				}
+				if part.ContentType != "image/png" {
+					t.Errorf("ContentType = %q, want image/png", part.ContentType)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantParts, ",") {
+				t.Errorf("parts = %q, want %q", got, tt.wantParts)
+			}
+		})
+	}
+}
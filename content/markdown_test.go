@@ -0,0 +1,101 @@
+package content_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bnixon67/onenote/content"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "paragraphs",
+			body: `<p>one <b>two</b> <i>three</i></p><h2>Head</h2>`,
+			want: "one **two** *three*\n\n## Head\n",
+		},
+		{
+			name: "escapes",
+			body: `<p>a *b* [c] x_y &lt;d&gt; |e|</p>`,
+			want: "a \\*b\\* \\[c\\] x\\_y \\<d\\> \\|e\\|\n",
+		},
+		{
+			name: "leading markers",
+			body: `<p>1. not a list</p><p>12) nor this</p><p>- no bullet</p><p>+ no plus</p><p># no heading</p>`,
+			want: "1\\. not a list\n\n12\\) nor this\n\n\\- no bullet\n\n\\+ no plus\n\n\\# no heading\n",
+		},
+		{
+			name: "inner markers",
+			body: `<p>pages 1. to 3 - done + more</p>`,
+			want: "pages 1. to 3 - done + more\n",
+		},
+		{
+			name: "links and code",
+			body: `<p><a href="https://example.com/a b">site</a> <code>x` + "`" + `y</code></p>`,
+			want: "[site](<https://example.com/a b>) ``x`y``\n",
+		},
+		{
+			name: "to-do",
+			body: `<p data-tag="to-do">open</p><p data-tag="to-do:completed">done</p>`,
+			want: "- [ ] open\n\n- [x] done\n",
+		},
+		{
+			name: "lists",
+			body: `<ol><li>one<ul><li>inner</li></ul></li><li data-tag="to-do">two</li></ol>`,
+			want: "1. one\n   - inner\n2. [ ] two\n",
+		},
+		{
+			name: "table",
+			body: `<table><tr><th>a</th><th>b</th></tr><tr><td><b>1</b></td><td><p>x</p><p>y</p></td></tr></table>`,
+			want: "| a | b |\n| --- | --- |\n| **1** | x<br>y |\n",
+		},
+		{
+			name: "table spans",
+			body: `<table><tr><td colspan="2">wide</td><td rowspan="2">tall</td></tr><tr><td>1</td><td>2</td></tr></table>`,
+			want: "| wide | wide | tall |\n| --- | --- | --- |\n| 1 | 2 | tall |\n",
+		},
+		{
+			name: "image",
+			body: `<img alt="a [chart]" src="https://graph.microsoft.com/r/1" />`,
+			want: "![a \\[chart\\]](https://graph.microsoft.com/r/1)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := content.ParseString(page + tt.body + `</body></html>`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.Markdown(&content.MarkdownOptions{NoFrontMatter: true}); got != tt.want {
+				t.Errorf("Markdown() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownFrontMatter(t *testing.T) {
+	p, err := content.ParseString(page + `<img src="https://graph.microsoft.com/r/1" data-fullres-src="https://graph.microsoft.com/r/2" />` +
+		`<div style="position:absolute;left:0px;top:0px"><p>first</p></div></body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := p.Markdown(&content.MarkdownOptions{
+		Meta:     map[string]string{"section": "Work", "id": "1"},
+		Resource: func(url string) string { return "images/" + url[len(url)-1:] + ".png" },
+	})
+	want := "---\ntitle: \"Notes\"\ncreated: \"2024-03-01T10:15:00.0000000\"\nid: \"1\"\nsection: \"Work\"\n---\n\n" +
+		"first\n\n![](images/2.png)\n"
+	if got != want {
+		t.Errorf("Markdown() =\n%q\nwant\n%q", got, want)
+	}
+
+	if got, want := p.Resources(), []string{"https://graph.microsoft.com/r/2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Resources() = %q, want %q", got, want)
+	}
+}
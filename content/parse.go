@@ -0,0 +1,489 @@
+package content
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// selfClosing matches XHTML style self-closing tags such as <object />
+var selfClosing = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9]*)(\s[^<>]*?)?\s*/>`)
+
+// voidElements may be self-closing in HTML
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"source": true, "track": true, "wbr": true,
+}
+
+// closeElements closes self-closing elements that are not void, which
+// OneNote returns for objects and iframes but HTML parsers leave open
+func closeElements(b []byte) []byte {
+	return selfClosing.ReplaceAllFunc(b, func(tag []byte) []byte {
+		m := selfClosing.FindSubmatch(tag)
+		name := string(m[1])
+		if voidElements[strings.ToLower(name)] {
+			return tag
+		}
+		return []byte("<" + name + string(m[2]) + "></" + name + ">")
+	})
+}
+
+// Parse parses the HTML of a page as returned by GetPageContent
+func Parse(r io.Reader) (*Page, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(closeElements(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{}
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Html:
+				page.Lang = attr(n, "lang")
+			case atom.Title:
+				page.Title = strings.TrimSpace(textOf(n))
+				return
+			case atom.Meta:
+				if attr(n, "name") == "created" {
+					page.Created = attr(n, "content")
+				}
+				return
+			case atom.Body:
+				page.Body = attrs(n)
+				page.Blocks = blocks(n)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+
+	return page, nil
+}
+
+// ParseString parses the HTML of a page as returned by GetPageContent
+func ParseString(s string) (*Page, error) {
+	return Parse(strings.NewReader(s))
+}
+
+// attr returns the value of the attribute key of n
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// attrs returns the common attributes of n
+func attrs(n *html.Node) Attrs {
+	return Attrs{
+		ID:     attr(n, "id"),
+		DataID: attr(n, "data-id"),
+		Tags:   parseTags(attr(n, "data-tag")),
+		Style:  attr(n, "style"),
+	}
+}
+
+// textOf returns the text in n
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+	}
+	return b.String()
+}
+
+// styleValue returns the value of a CSS property in an inline style
+func styleValue(style, property string) string {
+	for _, decl := range strings.Split(style, ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), property) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// pixels returns the integer part of a CSS length such as "624px"
+func pixels(value string) int {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return int(f)
+}
+
+// position returns the position of an absolutely positioned node
+func position(n *html.Node) *Position {
+	style := attr(n, "style")
+	if styleValue(style, "position") != "absolute" {
+		return nil
+	}
+	return &Position{
+		Left:   pixels(styleValue(style, "left")),
+		Top:    pixels(styleValue(style, "top")),
+		Width:  pixels(styleValue(style, "width")),
+		Height: pixels(styleValue(style, "height")),
+	}
+}
+
+// isInline reports whether n is text or an element within a paragraph
+func isInline(n *html.Node) bool {
+	switch n.Type {
+	case html.TextNode:
+		return true
+	case html.ElementNode:
+		switch n.DataAtom {
+		case atom.Span, atom.A, atom.B, atom.Strong, atom.I, atom.Em,
+			atom.U, atom.S, atom.Strike, atom.Del, atom.Sup, atom.Sub,
+			atom.Code, atom.Br, atom.Cite, atom.Font:
+			return true
+		}
+	}
+	return false
+}
+
+// blocks returns the blocks in the children of n, consecutive inline
+// nodes form a Paragraph without attributes
+func blocks(n *html.Node) []Block {
+	var result []Block
+	var pending []*html.Node
+
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		var runs []Run
+		for _, c := range pending {
+			runs = inlines(c, Format{}, "", runs)
+		}
+		pending = nil
+		if runs = trimRuns(runs); len(runs) > 0 {
+			result = append(result, &Paragraph{Runs: runs})
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if isInline(c) {
+			pending = append(pending, c)
+			continue
+		}
+		flush()
+		if c.Type == html.ElementNode {
+			result = append(result, block(c)...)
+		}
+	}
+	flush()
+
+	return result
+}
+
+// block returns the nodes for the block element n
+func block(n *html.Node) []Block {
+	a := attrs(n)
+
+	switch n.DataAtom {
+	case atom.Div:
+		if pos := position(n); pos != nil {
+			return []Block{&Outline{Attrs: a, Position: *pos, Blocks: blocks(n)}}
+		}
+		// a div used for grouping, keep its content
+		return blocks(n)
+
+	case atom.P:
+		return []Block{&Paragraph{Attrs: a, Runs: trimRuns(inlineChildren(n))}}
+
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		return []Block{&Heading{Attrs: a, Level: level, Runs: trimRuns(inlineChildren(n))}}
+
+	case atom.Ul, atom.Ol:
+		return []Block{list(n)}
+
+	case atom.Table:
+		return []Block{table(n)}
+
+	case atom.Img:
+		return []Block{&Image{
+			Attrs:          a,
+			Src:            attr(n, "src"),
+			SrcType:        attr(n, "data-src-type"),
+			FullResSrc:     attr(n, "data-fullres-src"),
+			FullResSrcType: attr(n, "data-fullres-src-type"),
+			Alt:            attr(n, "alt"),
+			Width:          pixels(attr(n, "width")),
+			Height:         pixels(attr(n, "height")),
+			Position:       position(n),
+		}}
+
+	case atom.Object:
+		return []Block{&Object{
+			Attrs:    a,
+			Name:     attr(n, "data-attachment"),
+			Type:     attr(n, "type"),
+			Data:     attr(n, "data"),
+			Position: position(n),
+		}}
+	}
+
+	var b strings.Builder
+	html.Render(&b, n)
+	return []Block{&Raw{Attrs: a, Tag: n.Data, HTML: b.String()}}
+}
+
+// list returns the List for a ul or ol element, a list directly in a
+// list is nested in the item before it
+func list(n *html.Node) *List {
+	l := &List{Attrs: attrs(n), Ordered: n.DataAtom == atom.Ol}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+
+		switch c.DataAtom {
+		case atom.Li:
+			item := &ListItem{Attrs: attrs(c)}
+			var inline []*html.Node
+			for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
+				if isInline(gc) {
+					inline = append(inline, gc)
+				} else if gc.Type == html.ElementNode {
+					item.Blocks = append(item.Blocks, block(gc)...)
+				}
+			}
+			for _, in := range inline {
+				item.Runs = inlines(in, Format{}, "", item.Runs)
+			}
+			item.Runs = trimRuns(item.Runs)
+			l.Items = append(l.Items, item)
+
+		case atom.Ul, atom.Ol:
+			nested := list(c)
+			if len(l.Items) == 0 {
+				l.Items = append(l.Items, &ListItem{})
+			}
+			last := l.Items[len(l.Items)-1]
+			last.Blocks = append(last.Blocks, nested)
+		}
+	}
+
+	return l
+}
+
+// table returns the Table for a table element
+func table(n *html.Node) *Table {
+	t := &Table{Attrs: attrs(n)}
+
+	var rows func(n *html.Node)
+	rows = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				rows(c)
+			case atom.Tr:
+				row := &Row{Attrs: attrs(c)}
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.DataAtom == atom.Td || cell.DataAtom == atom.Th {
+						row.Cells = append(row.Cells, &Cell{
+							Attrs:   attrs(cell),
+							Header:  cell.DataAtom == atom.Th,
+							ColSpan: span(cell, "colspan"),
+							RowSpan: span(cell, "rowspan"),
+							Blocks:  blocks(cell),
+						})
+					}
+				}
+				t.Rows = append(t.Rows, row)
+			}
+		}
+	}
+	rows(n)
+
+	return t
+}
+
+// Largest spans of a cell, as in the HTML table model
+const (
+	maxColSpan = 1000
+	maxRowSpan = 65534
+)
+
+// span returns the colspan or rowspan of a cell, 1 if not set or invalid
+// and at most the largest span
+func span(n *html.Node, key string) int {
+	v, err := strconv.Atoi(strings.TrimSpace(attr(n, key)))
+	if err != nil || v < 1 {
+		return 1
+	}
+
+	limit := maxColSpan
+	if key == "rowspan" {
+		limit = maxRowSpan
+	}
+	if v > limit {
+		return limit
+	}
+	return v
+}
+
+// inlineChildren returns the runs of the children of n
+func inlineChildren(n *html.Node) []Run {
+	var runs []Run
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		runs = inlines(c, Format{}, "", runs)
+	}
+	return runs
+}
+
+// inlines appends the runs of n with the formatting f and link href
+func inlines(n *html.Node, f Format, href string, runs []Run) []Run {
+	switch n.Type {
+	case html.TextNode:
+		return appendText(runs, collapseSpace(n.Data), f, href)
+	case html.ElementNode:
+	default:
+		return runs
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return append(runs, Run{Break: true})
+	case atom.A:
+		href = attr(n, "href")
+	case atom.B, atom.Strong:
+		f.Bold = true
+	case atom.I, atom.Em, atom.Cite:
+		f.Italic = true
+	case atom.U:
+		f.Underline = true
+	case atom.S, atom.Strike, atom.Del:
+		f.Strike = true
+	case atom.Sup:
+		f.Superscript = true
+	case atom.Sub:
+		f.Subscript = true
+	case atom.Code:
+		f.Code = true
+	}
+	f = styleFormat(attr(n, "style"), f)
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		runs = inlines(c, f, href, runs)
+	}
+	return runs
+}
+
+// styleFormat adds the formatting set by inline CSS, as OneNote uses
+// spans with styles rather than b or i elements
+func styleFormat(style string, f Format) Format {
+	if style == "" {
+		return f
+	}
+
+	switch styleValue(style, "font-weight") {
+	case "bold", "bolder", "600", "700", "800", "900":
+		f.Bold = true
+	}
+	if styleValue(style, "font-style") == "italic" {
+		f.Italic = true
+	}
+	decoration := styleValue(style, "text-decoration")
+	if strings.Contains(decoration, "underline") {
+		f.Underline = true
+	}
+	if strings.Contains(decoration, "line-through") {
+		f.Strike = true
+	}
+	switch styleValue(style, "vertical-align") {
+	case "super":
+		f.Superscript = true
+	case "sub":
+		f.Subscript = true
+	}
+	if font := strings.ToLower(styleValue(style, "font-family")); strings.Contains(font, "consolas") ||
+		strings.Contains(font, "courier") || strings.Contains(font, "monospace") {
+		f.Code = true
+	}
+	return f
+}
+
+// collapseSpace replaces runs of white space with one space as browsers
+// do, the non-breaking spaces used by OneNote are kept
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// appendText adds text to runs, merging it with the last run if the
+// formatting and link are the same
+func appendText(runs []Run, text string, f Format, href string) []Run {
+	if text == "" {
+		return runs
+	}
+	if n := len(runs); n > 0 && !runs[n-1].Break && runs[n-1].Format == f && runs[n-1].Href == href {
+		// avoid two spaces where elements meet
+		if strings.HasSuffix(runs[n-1].Text, " ") {
+			text = strings.TrimPrefix(text, " ")
+		}
+		runs[n-1].Text += text
+		return runs
+	}
+	return append(runs, Run{Text: text, Format: f, Href: href})
+}
+
+// trimRuns removes the white space at the start and end of runs and
+// drops empty runs
+func trimRuns(runs []Run) []Run {
+	for len(runs) > 0 && !runs[0].Break {
+		runs[0].Text = strings.TrimLeft(runs[0].Text, " ")
+		if runs[0].Text != "" {
+			break
+		}
+		runs = runs[1:]
+	}
+	for n := len(runs); n > 0 && !runs[n-1].Break; n = len(runs) {
+		runs[n-1].Text = strings.TrimRight(runs[n-1].Text, " ")
+		if runs[n-1].Text != "" {
+			break
+		}
+		runs = runs[:n-1]
+	}
+	return runs
+}
@@ -0,0 +1,200 @@
+package content_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote/content"
+)
+
+// page is the start of a page as returned by GetPageContent
+const page = `<html lang="en-US"><head><title>Notes</title>` +
+	`<meta name="created" content="2024-03-01T10:15:00.0000000" /></head>` +
+	`<body data-absolute-enabled="true" style="font-family:Calibri">`
+
+func TestParsePage(t *testing.T) {
+	p, err := content.ParseString(page + `</body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Notes" || p.Lang != "en-US" || p.Body.Style != "font-family:Calibri" {
+		t.Errorf("page = %q %q %q", p.Title, p.Lang, p.Body.Style)
+	}
+	if got := p.CreatedTime().Format("2006-01-02 15:04"); got != "2024-03-01 10:15" {
+		t.Errorf("CreatedTime() = %s", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []content.Block
+	}{
+		{
+			name: "outline",
+			body: `<div id="div:{1}{1}" data-id="o1" style="position:absolute;left:48px;top:120px;width:624.5px">` +
+				`<p id="p:{1}{2}">first</p></div>`,
+			want: []content.Block{&content.Outline{
+				Attrs:    content.Attrs{ID: "div:{1}{1}", DataID: "o1", Style: "position:absolute;left:48px;top:120px;width:624.5px"},
+				Position: content.Position{Left: 48, Top: 120, Width: 624},
+				Blocks: []content.Block{&content.Paragraph{
+					Attrs: content.Attrs{ID: "p:{1}{2}"},
+					Runs:  []content.Run{{Text: "first"}},
+				}},
+			}},
+		},
+		{
+			name: "grouping div",
+			body: `<div><h2 data-tag="important">  Plan </h2></div>`,
+			want: []content.Block{&content.Heading{
+				Attrs: content.Attrs{Tags: []content.Tag{{Name: "important"}}},
+				Level: 2,
+				Runs:  []content.Run{{Text: "Plan"}},
+			}},
+		},
+		{
+			name: "formatting",
+			body: `<p>a <b>bold</b> <span style="font-style:italic;text-decoration:underline">it</span>` +
+				`<br /><a href="https://example.com"><code>x</code></a> <sup>2</sup></p>`,
+			want: []content.Block{&content.Paragraph{Runs: []content.Run{
+				{Text: "a "},
+				{Text: "bold", Format: content.Format{Bold: true}},
+				{Text: " "},
+				{Text: "it", Format: content.Format{Italic: true, Underline: true}},
+				{Break: true},
+				{Text: "x", Format: content.Format{Code: true}, Href: "https://example.com"},
+				{Text: " "},
+				{Text: "2", Format: content.Format{Superscript: true}},
+			}}},
+		},
+		{
+			name: "white space",
+			body: "<p>\n  two\t\tspaces&nbsp; kept \n</p>",
+			want: []content.Block{&content.Paragraph{Runs: []content.Run{{Text: "two spaces\u00a0 kept"}}}},
+		},
+		{
+			name: "text in body",
+			body: `loose <i>text</i><p>p</p>`,
+			want: []content.Block{
+				&content.Paragraph{Runs: []content.Run{{Text: "loose "}, {Text: "text", Format: content.Format{Italic: true}}}},
+				&content.Paragraph{Runs: []content.Run{{Text: "p"}}},
+			},
+		},
+		{
+			name: "nested list",
+			body: `<ol><li data-tag="to-do:completed">one</li><ul><li>inner</li></ul><li>two<p>more</p></li></ol>`,
+			want: []content.Block{&content.List{Ordered: true, Items: []*content.ListItem{
+				{
+					Attrs: content.Attrs{Tags: []content.Tag{{Name: "to-do", Completed: true}}},
+					Runs:  []content.Run{{Text: "one"}},
+					Blocks: []content.Block{&content.List{Items: []*content.ListItem{
+						{Runs: []content.Run{{Text: "inner"}}},
+					}}},
+				},
+				{
+					Runs:   []content.Run{{Text: "two"}},
+					Blocks: []content.Block{&content.Paragraph{Runs: []content.Run{{Text: "more"}}}},
+				},
+			}}},
+		},
+		{
+			name: "table",
+			body: `<table><tr><th colspan="2">h</th></tr><tr><td rowspan="x">a</td><td>b</td></tr></table>`,
+			want: []content.Block{&content.Table{Rows: []*content.Row{
+				{Cells: []*content.Cell{
+					{Header: true, ColSpan: 2, RowSpan: 1, Blocks: []content.Block{&content.Paragraph{Runs: []content.Run{{Text: "h"}}}}},
+				}},
+				{Cells: []*content.Cell{
+					{ColSpan: 1, RowSpan: 1, Blocks: []content.Block{&content.Paragraph{Runs: []content.Run{{Text: "a"}}}}},
+					{ColSpan: 1, RowSpan: 1, Blocks: []content.Block{&content.Paragraph{Runs: []content.Run{{Text: "b"}}}}},
+				}},
+			}}},
+		},
+		{
+			name: "image",
+			body: `<img src="https://graph.microsoft.com/v1.0/me/onenote/resources/1/$value" data-src-type="image/png" ` +
+				`alt="chart" width="320" height="200.5" style="position:absolute;left:10px;top:20px" />`,
+			want: []content.Block{&content.Image{
+				Attrs:    content.Attrs{Style: "position:absolute;left:10px;top:20px"},
+				Src:      "https://graph.microsoft.com/v1.0/me/onenote/resources/1/$value",
+				SrcType:  "image/png",
+				Alt:      "chart",
+				Width:    320,
+				Height:   200,
+				Position: &content.Position{Left: 10, Top: 20},
+			}},
+		},
+		{
+			name: "self-closing object",
+			body: `<object data-attachment="report.pdf" type="application/pdf" data="https://graph.microsoft.com/r/2" /><p>after</p>`,
+			want: []content.Block{
+				&content.Object{Name: "report.pdf", Type: "application/pdf", Data: "https://graph.microsoft.com/r/2"},
+				&content.Paragraph{Runs: []content.Run{{Text: "after"}}},
+			},
+		},
+		{
+			name: "raw",
+			body: `<iframe data-original-src="https://www.youtube.com/watch?v=1" />`,
+			want: []content.Block{&content.Raw{
+				Tag:  "iframe",
+				HTML: `<iframe data-original-src="https://www.youtube.com/watch?v=1"></iframe>`,
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := content.ParseString(page + tt.body + `</body></html>`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p.Blocks, tt.want) {
+				t.Errorf("Blocks =\n%s\nwant\n%s", dump(p.Blocks), dump(tt.want))
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	p, err := content.ParseString(page + `<div style="position:absolute">` +
+		`<p id="p:{1}{1}" data-id="first">a</p><table><tr><td><p id="p:{1}{2}">b</p></td></tr></table>` +
+		`</div></body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"#first", "a"},
+		{"p:{1}{1}", "a"},
+		{"p:{1}{2}", "b"},
+		{"#p:{1}{2}", ""},
+		{"#missing", ""},
+	}
+	for _, tt := range tests {
+		b := p.Find(tt.target)
+		var got string
+		if para, ok := b.(*content.Paragraph); ok {
+			got = para.Text()
+		}
+		if got != tt.want {
+			t.Errorf("Find(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
+
+// dump returns the blocks and the blocks nested in them for error
+// messages
+func dump(blocks []content.Block) string {
+	var b strings.Builder
+	content.Walk(blocks, func(block content.Block) bool {
+		fmt.Fprintf(&b, "%T %+v\n", block, block)
+		return true
+	})
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package content
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+)
+
+// Tables returns the tables of the page in document order, including
+// tables nested in table cells
+func (p *Page) Tables() []*Table {
+	var tables []*Table
+	Walk(p.Blocks, func(b Block) bool {
+		if t, ok := b.(*Table); ok {
+			tables = append(tables, t)
+		}
+		return true
+	})
+	return tables
+}
+
+// Grid returns the text of the cells by row and column, see Text. A cell
+// spanning several columns or rows is repeated in each of them, and rows
+// are padded so all have the same number of columns.
+func (t *Table) Grid() [][]string {
+	return t.grid(func(c *Cell) string { return Text(c) })
+}
+
+// grid returns the cells by row and column like Grid, with the content
+// of each cell given by text
+func (t *Table) grid(text func(*Cell) string) [][]string {
+	grid := make([][]string, len(t.Rows))
+	filled := make([][]bool, len(t.Rows))
+
+	set := func(r, c int, s string) {
+		for len(grid[r]) <= c {
+			grid[r] = append(grid[r], "")
+			filled[r] = append(filled[r], false)
+		}
+		grid[r][c] = s
+		filled[r][c] = true
+	}
+
+	for r, row := range t.Rows {
+		c := 0
+		for _, cell := range row.Cells {
+			// skip the columns taken by cells spanning from rows above
+			for c < len(filled[r]) && filled[r][c] {
+				c++
+			}
+
+			s := text(cell)
+			for i := 0; i < cell.RowSpan && r+i < len(t.Rows); i++ {
+				for j := 0; j < cell.ColSpan; j++ {
+					set(r+i, c+j, s)
+				}
+			}
+			c += cell.ColSpan
+		}
+	}
+
+	columns := 0
+	for _, row := range grid {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	for r := range grid {
+		for len(grid[r]) < columns {
+			grid[r] = append(grid[r], "")
+		}
+	}
+
+	return grid
+}
+
+// WriteCSV writes the Grid of the table as CSV
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(t.Grid()); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteJSON writes the Grid of the table as a JSON array of rows, each
+// an array of cells
+func (t *Table) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(t.Grid())
+}
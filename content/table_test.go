@@ -0,0 +1,105 @@
+package content_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote/content"
+)
+
+// parseTable returns the first table of the page with body
+func parseTable(t *testing.T, body string) *content.Table {
+	t.Helper()
+	p, err := content.ParseString(page + body + `</body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := p.Tables()
+	if len(tables) == 0 {
+		t.Fatalf("no table in %s", body)
+	}
+	return tables[0]
+}
+
+func TestTableGrid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want [][]string
+	}{
+		{
+			name: "plain",
+			body: `<table><tr><td>a</td><td>b</td></tr><tr><td>c</td><td>d</td></tr></table>`,
+			want: [][]string{{"a", "b"}, {"c", "d"}},
+		},
+		{
+			name: "colspan",
+			body: `<table><tr><td colspan="2">a</td><td>b</td></tr><tr><td>c</td></tr></table>`,
+			want: [][]string{{"a", "a", "b"}, {"c", "", ""}},
+		},
+		{
+			name: "rowspan",
+			body: `<table><tr><td rowspan="2">a</td><td>b</td></tr><tr><td>c</td></tr><tr><td>d</td></tr></table>`,
+			want: [][]string{{"a", "b"}, {"a", "c"}, {"d", ""}},
+		},
+		{
+			name: "rowspan past the end",
+			body: `<table><tr><td rowspan="5">a</td><td>b</td></tr></table>`,
+			want: [][]string{{"a", "b"}},
+		},
+		{
+			name: "invalid spans",
+			body: `<table><tr><td colspan="0">a</td><td colspan="-3" rowspan="x">b</td></tr></table>`,
+			want: [][]string{{"a", "b"}},
+		},
+		{
+			name: "nested",
+			body: `<table><tr><td><p>a</p><table><tr><td>x</td></tr></table></td></tr></table>`,
+			want: [][]string{{"a\nx"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseTable(t, tt.body).Grid(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Grid() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableSpanLimit(t *testing.T) {
+	table := parseTable(t, `<table><tr><td colspan="100000000" rowspan="100000000">a</td></tr><tr><td>b</td></tr></table>`)
+
+	cell := table.Rows[0].Cells[0]
+	if cell.ColSpan != 1000 || cell.RowSpan != 65534 {
+		t.Errorf("spans = %d, %d, want 1000, 65534", cell.ColSpan, cell.RowSpan)
+	}
+
+	grid := table.Grid()
+	if len(grid) != 2 || len(grid[0]) != 1001 || grid[1][999] != "a" || grid[1][1000] != "b" {
+		t.Errorf("Grid() has %d rows, first of %d columns", len(grid), len(grid[0]))
+	}
+}
+
+func TestTableWrite(t *testing.T) {
+	table := parseTable(t, `<table><tr><td>a, b</td><td>"c"</td></tr><tr><td colspan="2">d</td></tr></table>`)
+
+	var csv bytes.Buffer
+	if err := table.WriteCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\"a, b\",\"\"\"c\"\"\"\nd,d\n"; csv.String() != want {
+		t.Errorf("WriteCSV() = %q, want %q", csv.String(), want)
+	}
+
+	var js bytes.Buffer
+	if err := table.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	if want := `[["a, b","\"c\""],["d","d"]]`; strings.TrimSpace(js.String()) != want {
+		t.Errorf("WriteJSON() = %s, want %s", js.String(), want)
+	}
+}
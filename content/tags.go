@@ -0,0 +1,81 @@
+package content
+
+// Tagged is an element with note tags
+type Tagged struct {
+	// Page is the page holding the element
+	Page *Page
+
+	// Outline holds the element, nil for images and attachments placed
+	// on the page
+	Outline *Outline
+
+	// Element is the tagged node, its Target is the PATCH target
+	Element Block
+
+	// Tags of the element, such as to-do, important or custom tags
+	Tags []Tag
+
+	// Text is the full text of the element and the elements within it
+	Text string
+}
+
+// Has reports whether the element has the tag, such as "to-do"
+func (t *Tagged) Has(name string) bool {
+	return t.Element.Attributes().HasTag(name)
+}
+
+// Completed reports whether a tag of the element is checked,
+// such as to-do:completed
+func (t *Tagged) Completed() bool {
+	for _, tag := range t.Tags {
+		if tag.Completed {
+			return true
+		}
+	}
+	return false
+}
+
+// DataID returns the data-id of the element
+func (t *Tagged) DataID() string {
+	return t.Element.Attributes().DataID
+}
+
+// Tagged returns the elements of the page with any of the named tags,
+// or with any tag if no names are given, in document order
+func (p *Page) Tagged(names ...string) []Tagged {
+	var result []Tagged
+
+	for _, b := range p.Blocks {
+		outline, _ := b.(*Outline)
+		Walk([]Block{b}, func(b Block) bool {
+			a := b.Attributes()
+			if len(a.Tags) == 0 || !hasAny(a, names) {
+				return true
+			}
+			result = append(result, Tagged{
+				Page:    p,
+				Outline: outline,
+				Element: b,
+				Tags:    a.Tags,
+				Text:    Text(b),
+			})
+			return true
+		})
+	}
+
+	return result
+}
+
+// hasAny reports whether a has one of the named tags, true if names is
+// empty
+func hasAny(a *Attrs, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if a.HasTag(name) {
+			return true
+		}
+	}
+	return false
+}
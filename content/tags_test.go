@@ -0,0 +1,95 @@
+package content_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bnixon67/onenote/content"
+)
+
+func TestTagged(t *testing.T) {
+	p, err := content.ParseString(page +
+		`<div style="position:absolute;left:0px;top:0px">` +
+		`<p data-id="a" data-tag="to-do">buy milk</p>` +
+		`<p data-tag="to-do:completed, important">call Bob</p>` +
+		`<p>untagged</p>` +
+		`<ul><li data-tag="question">why <b>now</b>?<ul><li data-tag="to-do">nested</li></ul></li></ul>` +
+		`<table><tr><td data-tag="idea"><p>cell</p></td></tr></table>` +
+		`</div>` +
+		`<img data-tag="remember-for-later" alt="chart" src="x" style="position:absolute;left:0px;top:300px" />` +
+		`<div style="position:absolute;left:0px;top:400px"><p data-tag="custom:tag-1">mine</p></div>` +
+		`</body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		names         []string
+		wantText      []string
+		wantCompleted []bool
+	}{
+		{"all", nil,
+			[]string{"buy milk", "call Bob", "why now?\nnested", "nested", "cell", "chart", "mine"},
+			[]bool{false, true, false, false, false, false, false}},
+		{"to-do", []string{"to-do"},
+			[]string{"buy milk", "call Bob", "nested"},
+			[]bool{false, true, false}},
+		{"any of", []string{"important", "idea"},
+			[]string{"call Bob", "cell"},
+			[]bool{true, false}},
+		{"custom", []string{"custom"}, []string{"mine"}, []bool{false}},
+		{"none", []string{"contact"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var text []string
+			var completed []bool
+			for _, tagged := range p.Tagged(tt.names...) {
+				text = append(text, tagged.Text)
+				completed = append(completed, tagged.Completed())
+				has := len(tt.names) == 0
+				for _, name := range tt.names {
+					has = has || tagged.Has(name)
+				}
+				if !has {
+					t.Errorf("%q has none of %q", tagged.Text, tt.names)
+				}
+			}
+			if !reflect.DeepEqual(text, tt.wantText) {
+				t.Errorf("Text = %q, want %q", text, tt.wantText)
+			}
+			if !reflect.DeepEqual(completed, tt.wantCompleted) {
+				t.Errorf("Completed() = %v, want %v", completed, tt.wantCompleted)
+			}
+		})
+	}
+
+	all := p.Tagged()
+	if all[0].DataID() != "a" || all[0].Outline == nil || all[0].Page != p {
+		t.Errorf("first = %q in outline %v", all[0].DataID(), all[0].Outline)
+	}
+	if img := all[5]; img.Outline != nil {
+		t.Errorf("image placed on the page has outline %v", img.Outline)
+	}
+	if got := all[1].Tags; !reflect.DeepEqual(got, []content.Tag{{Name: "to-do", Completed: true}, {Name: "important"}}) {
+		t.Errorf("Tags = %v", got)
+	}
+}
+
+func TestTagString(t *testing.T) {
+	tests := []struct {
+		tag  content.Tag
+		want string
+	}{
+		{content.Tag{Name: "to-do"}, "to-do"},
+		{content.Tag{Name: "to-do", Completed: true}, "to-do:completed"},
+		{content.Tag{Name: "important"}, "important"},
+	}
+	for _, tt := range tests {
+		if got := tt.tag.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
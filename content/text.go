@@ -0,0 +1,121 @@
+package content
+
+import (
+	"strings"
+)
+
+// TextOptions change the text extracted from a page
+type TextOptions struct {
+	// NoTitle leaves out the title, which is the first line otherwise
+	NoTitle bool
+
+	// NoTables leaves out tables
+	NoTables bool
+
+	// NoAltText leaves out the alt text of images
+	NoAltText bool
+}
+
+// Text returns the plain text of the page for search or summaries.
+// The title, outlines and images placed on the page are in reading
+// order, top to bottom and then left to right, separated by blank lines.
+// Within them there is one line per paragraph, heading, list item or
+// table row, with tabs between table cells. White space is collapsed.
+func (p *Page) Text(opts *TextOptions) string {
+	if opts == nil {
+		opts = &TextOptions{}
+	}
+
+	var parts []string
+	if !opts.NoTitle {
+		if title := collapseLine(p.Title); title != "" {
+			parts = append(parts, title)
+		}
+	}
+	for _, b := range ReadingOrder(p.Blocks) {
+		if s := blockText(b, opts); s != "" {
+			parts = append(parts, s)
+		}
+	}
+
+	return strings.Join(parts, "\n\n")
+}
+
+// Text returns the text of a block and the blocks within it, with one
+// line per paragraph, heading, list item or table row and tabs between
+// table cells. Images are their alt text and attachments their name.
+func Text(b Block) string {
+	return blockText(b, &TextOptions{})
+}
+
+// blockText returns the text of a block with the options, without empty
+// lines
+func blockText(b Block, opts *TextOptions) string {
+	var lines []string
+	add := func(text string) {
+		for _, line := range strings.Split(text, "\n") {
+			if line = collapseLine(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	var walk func(b Block)
+	walk = func(b Block) {
+		switch t := b.(type) {
+		case *Paragraph:
+			add(t.Text())
+		case *Heading:
+			add(t.Text())
+		case *ListItem:
+			add(t.Text())
+			for _, nested := range t.Blocks {
+				walk(nested)
+			}
+		case *List:
+			for _, item := range t.Items {
+				walk(item)
+			}
+		case *Table:
+			if opts.NoTables {
+				return
+			}
+			for _, row := range t.Rows {
+				walk(row)
+			}
+		case *Row:
+			cells := make([]string, len(t.Cells))
+			empty := true
+			for i, cell := range t.Cells {
+				cells[i] = strings.ReplaceAll(blockText(cell, opts), "\n", " ")
+				empty = empty && cells[i] == ""
+			}
+			if !empty {
+				lines = append(lines, strings.Join(cells, "\t"))
+			}
+		case *Cell:
+			for _, nested := range t.Blocks {
+				walk(nested)
+			}
+		case *Outline:
+			for _, nested := range t.Blocks {
+				walk(nested)
+			}
+		case *Image:
+			if !opts.NoAltText {
+				add(t.Alt)
+			}
+		case *Object:
+			add(t.Name)
+		}
+	}
+	walk(b)
+
+	return strings.Join(lines, "\n")
+}
+
+// collapseLine replaces runs of white space in a line, including the
+// non-breaking spaces used by OneNote, with one space
+func collapseLine(line string) string {
+	return strings.Join(strings.Fields(line), " ")
+}
@@ -0,0 +1,90 @@
+package content_test
+
+import (
+	"testing"
+
+	"github.com/bnixon67/onenote/content"
+)
+
+func TestText(t *testing.T) {
+	p, err := content.ParseString(page +
+		`<div style="position:absolute;left:0px;top:400px">` +
+		`<p>last</p></div>` +
+		`<div style="position:absolute;left:300px;top:10px">` +
+		`<h1>right</h1>` +
+		`<table><tr><td>a</td><td><p>b</p><p>c</p></td></tr><tr><td></td><td></td></tr><tr><td>d</td><td></td></tr></table>` +
+		`</div>` +
+		`<img alt="chart" src="x" style="position:absolute;left:0px;top:200px" />` +
+		`<div style="position:absolute;left:0px;top:10px">` +
+		"<p>one <b>two</b>  three<br />  four  </p><p> </p>" +
+		`<ul><li>milk<ul><li>oat</li></ul></li><li>eggs</li></ul>` +
+		`<img alt="inline" src="y" />` +
+		`<object data-attachment="notes.txt" data="z" type="text/plain" />` +
+		`</div>` +
+		`</body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts *content.TextOptions
+		want string
+	}{
+		{
+			"default",
+			nil,
+			"Notes\n\n" +
+				"one two three\nfour\nmilk\noat\neggs\ninline\nnotes.txt\n\n" +
+				"right\na\tb c\nd\t\n\n" +
+				"chart\n\n" +
+				"last",
+		},
+		{
+			"no title",
+			&content.TextOptions{NoTitle: true},
+			"one two three\nfour\nmilk\noat\neggs\ninline\nnotes.txt\n\n" +
+				"right\na\tb c\nd\t\n\n" +
+				"chart\n\n" +
+				"last",
+		},
+		{
+			"no tables",
+			&content.TextOptions{NoTables: true},
+			"Notes\n\n" +
+				"one two three\nfour\nmilk\noat\neggs\ninline\nnotes.txt\n\n" +
+				"right\n\n" +
+				"chart\n\n" +
+				"last",
+		},
+		{
+			"no alt text",
+			&content.TextOptions{NoAltText: true},
+			"Notes\n\n" +
+				"one two three\nfour\nmilk\noat\neggs\nnotes.txt\n\n" +
+				"right\na\tb c\nd\t\n\n" +
+				"last",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Text(tt.opts); got != tt.want {
+				t.Errorf("Text() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockText(t *testing.T) {
+	p, err := content.ParseString(page +
+		`<div style="position:absolute;left:0px;top:0px">` +
+		`<table><tr><td><img alt="logo" src="x" /></td><td>name</td></tr></table>` +
+		`</div></body></html>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := content.Text(p.Blocks[0]), "logo\tname"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+}
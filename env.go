@@ -0,0 +1,97 @@
+package onenote
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Environment is a Microsoft cloud with its own Graph and login hosts
+type Environment struct {
+	// Name used in configuration files, such as "global"
+	Name string
+
+	// GraphURL is the root of Microsoft Graph, without the version
+	GraphURL string
+
+	// LoginURL is the root of the Microsoft identity platform
+	LoginURL string
+}
+
+// national cloud deployments,
+// see https://learn.microsoft.com/graph/deployments
+var (
+	// Global is the global Microsoft cloud
+	Global = Environment{
+		Name:     "global",
+		GraphURL: "https://graph.microsoft.com",
+		LoginURL: "https://login.microsoftonline.com",
+	}
+
+	// USGovL4 is Microsoft Graph for US Government L4 (GCC High)
+	USGovL4 = Environment{
+		Name:     "usgov-l4",
+		GraphURL: "https://graph.microsoft.us",
+		LoginURL: "https://login.microsoftonline.us",
+	}
+
+	// USGovL5 is Microsoft Graph for US Government L5 (DOD)
+	USGovL5 = Environment{
+		Name:     "usgov-l5",
+		GraphURL: "https://dod-graph.microsoft.us",
+		LoginURL: "https://login.microsoftonline.us",
+	}
+
+	// China is Microsoft Graph China operated by 21Vianet
+	China = Environment{
+		Name:     "china",
+		GraphURL: "https://microsoftgraph.chinacloudapi.cn",
+		LoginURL: "https://login.chinacloudapi.cn",
+	}
+)
+
+// Environments lists the known environments
+var Environments = []Environment{Global, USGovL4, USGovL5, China}
+
+// EnvironmentByName returns the environment with the given name,
+// Global if name is empty
+func EnvironmentByName(name string) (Environment, error) {
+	if name == "" {
+		return Global, nil
+	}
+
+	for _, env := range Environments {
+		if strings.EqualFold(env.Name, name) {
+			return env, nil
+		}
+	}
+
+	return Environment{}, fmt.Errorf("onenote: unknown environment %q", name)
+}
+
+// Graph API versions
+const (
+	// V1 is the generally available API
+	V1 = "v1.0"
+
+	// Beta has preview features and fields that may change
+	Beta = "beta"
+)
+
+// baseURL returns the versioned root of Microsoft Graph for the Client
+func (c *Client) baseURL() string {
+	if c.BaseURL != "" {
+		return strings.TrimSuffix(c.BaseURL, "/")
+	}
+
+	env := c.Environment
+	if env.GraphURL == "" {
+		env = Global
+	}
+
+	version := c.Version
+	if version == "" {
+		version = V1
+	}
+
+	return env.GraphURL + "/" + version
+}
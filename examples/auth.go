@@ -3,8 +3,7 @@ package main
 import (
 	"bufio"
 	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -13,42 +12,20 @@ import (
 	"os"
 	"strings"
 
+	"github.com/bnixon67/onenote/auth"
 	"golang.org/x/oauth2"
 )
 
-const (
-	msBase        = "https://login.microsoftonline.com/common/oauth2/v2.0"
-	msAuthURL     = msBase + "/authorize"
-	msTokenURL    = msBase + "/token"
-	myRedirectURL = "https://login.microsoftonline.com/common/oauth2/nativeclient"
-)
-
-// writeToken writes out the oauth2 token to a file
-func writeToken(fileName string, token *oauth2.Token) {
-	// create token file
-	file, err := os.Create(fileName)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	defer file.Close()
-
-	// write access token string
-	_, err = file.WriteString(token.AccessToken)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	return
-}
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
 
 // appVars contains shared variables to avoid use of globals
 // TODO - should I use Context instead?
 type appVars struct {
-	conf   *oauth2.Config
-	ctx    context.Context
-	client *http.Client
-	state  string
+	conf    *oauth2.Config
+	ctx     context.Context
+	client  *http.Client
+	state   string
+	profile *auth.Profile
 }
 
 func (app *appVars) redeemToken(responseURI string) {
@@ -76,66 +53,49 @@ func (app *appVars) redeemToken(responseURI string) {
 	}
 
 	// write out token to file for reuse in other programs
-	writeToken("token.txt", token)
+	err = app.profile.WriteToken(token)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// update HTTP client with token
 	app.client = app.conf.Client(app.ctx, token)
 
-	fmt.Println("Authorization successful, token.txt update")
+	fmt.Println("Authorization successful,", app.profile.TokenStore, "updated")
 
 	return
 }
 
-// randomToken returns a securely generated token of size n
-func randomToken(n int) string {
-	// buffer to store n bytes
-	b := make([]byte, n)
-
-	// read random bytes based on size of b
-	_, err := rand.Read(b)
-	if err != nil {
-		log.Panic(err)
-	}
-
-	// convert buffer to URL friendly string
-	return base64.URLEncoding.EncodeToString(b)
-}
-
 // main authorizes via OAuth2 with Microsoft
-// two environmental variables must be set (MSCLIENTID and MSCLIENTSECRET)
-// token is written to token.txt file in the current directory
+// the client id comes from the profile or the MSCLIENTID environment variable
+// token is written to the token store of the profile (default token.txt)
 func main() {
+	flag.Parse()
 
 	app := &appVars{}
 
+	// get the account profile
+	profile, err := auth.LoadProfile(*profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	app.profile = profile
+
 	// get top-level context
 	app.ctx = context.Background()
 
-	// get Microsoft client id and secret stored in environment
-	// variables to avoid adding to source code repository
-	msClientId, present := os.LookupEnv("MSCLIENTID")
-	if !present {
-		log.Panic("Must set MSCLIENTID")
-	}
-	//msClientSecret, present := os.LookupEnv("MSCLIENTSECRET")
-	//if !present {
-	//	log.Panic("Must set MSCLIENTSECRET")
-	//}
-
 	// setup configuration for OAuth2
-	app.conf = &oauth2.Config{
-		ClientID: msClientId,
-		//ClientSecret: msClientSecret,
-		Scopes: []string{"Notes.Read", "offline_access"},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  msAuthURL,
-			TokenURL: msTokenURL,
-		},
-		RedirectURL: myRedirectURL,
+	app.conf = profile.Config(profile.NativeClientRedirectURL())
+	if app.conf.ClientID == "" {
+		log.Panic("Must set MSCLIENTID or clientId in the profile")
 	}
+	app.conf.Scopes = append(app.conf.Scopes, "offline_access")
+
+	// native clients do not use a client secret
+	app.conf.ClientSecret = ""
 
 	// generate a random token to prevent CSRF attacks
-	app.state = randomToken(32)
+	app.state = auth.RandomState(32)
 
 	// generate URL for user consent for permissions (scopes) above
 	url := app.conf.AuthCodeURL(app.state, oauth2.AccessTypeOffline)
@@ -151,7 +111,7 @@ func main() {
 	app.redeemToken(responseURI)
 
 	// try something
-	const tryURL = "https://graph.microsoft.com/v1.0/me/onenote/notebooks?$select=displayName"
+	tryURL := app.profile.Environment().GraphURL + "/v1.0/me/onenote/notebooks?$select=displayName"
 	fmt.Println("\nGET", tryURL)
 	resp, err := app.client.Get(tryURL)
 	if err != nil {
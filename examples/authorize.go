@@ -2,43 +2,22 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"flag"
 	"fmt"
+	"github.com/bnixon67/onenote/auth"
 	"golang.org/x/oauth2"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 )
 
-const (
-	msBase        = "https://login.microsoftonline.com/common/oauth2/v2.0"
-	msAuthURL     = msBase + "/authorize"
-	msTokenURL    = msBase + "/token"
-	myRedirectURL = "http://localhost:9999/oauth/callback"
-)
-
-// writeToken writes out the oauth2 token to a file
-func writeToken(fileName string, token *oauth2.Token) {
-	// create token file
-	file, err := os.Create(fileName)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	defer file.Close()
-
-	// write access token string
-	_, err = file.WriteString(token.AccessToken)
-	if err != nil {
-		log.Fatal(err)
-	}
+const myRedirectURL = "http://localhost:9999/oauth/callback"
 
-	return
-}
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var addScopes = flag.String("add", "", "comma separated `scopes` to consent to in addition to the profile scopes")
 
 // appVars contains shared variables to avoid use of globals
 // TODO - should I use Context instead?
@@ -48,6 +27,7 @@ type appVars struct {
 	client   *http.Client
 	state    string
 	authChan chan bool
+	profile  *auth.Profile
 }
 
 // oauthRedirect handles the redirect from the resource owner
@@ -71,12 +51,17 @@ func (app *appVars) oauthRedirect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// write out token to file for reuse in other programs
-	writeToken("token.txt", token)
+	err = app.profile.WriteToken(token)
+	if err != nil {
+		log.Println("WriteToken", err)
+		app.authChan <- false
+		return
+	}
 
 	// update HTTP client with token
 	app.client = app.conf.Client(app.ctx, token)
 
-	fmt.Fprintf(w, "Authorization successful, token.txt update")
+	fmt.Fprintf(w, "Authorization successful, %s updated", app.profile.TokenStore)
 
 	// signal that authorization was successful
 	app.authChan <- true
@@ -84,56 +69,54 @@ func (app *appVars) oauthRedirect(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// randomToken returns a securely generated token of size n
-func randomToken(n int) string {
-	// buffer to store n bytes
-	b := make([]byte, n)
-
-	// read random bytes based on size of b
-	_, err := rand.Read(b)
-	if err != nil {
-		log.Panic(err)
-	}
-
-	// convert buffer to URL friendly string
-	return base64.URLEncoding.EncodeToString(b)
-}
-
 // main authorizes via OAuth2 with Microsoft
-// two environmental variables must be set (MSCLIENTID and MSCLIENTSECRET)
-// token is written to token.txt file in the current directory
+// client id and secret come from the profile or the environment
+// variables MSCLIENTID and MSCLIENTSECRET
+// token is written to the token store of the profile (default token.txt)
 func main() {
+	flag.Parse()
 
 	app := &appVars{}
 
+	// get the account profile
+	profile, err := auth.LoadProfile(*profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	app.profile = profile
+
 	// get top-level context
 	app.ctx = context.Background()
 
-	// get Microsoft client id and secret stored in environment
-	// variables to avoid adding to source code repository
-	msClientId, present := os.LookupEnv("MSCLIENTID")
-	if !present {
-		log.Panic("Must set MSCLIENTID")
+	// setup configuration for OAuth2
+	app.conf = profile.Config(myRedirectURL)
+	if app.conf.ClientID == "" {
+		log.Panic("Must set MSCLIENTID or clientId in the profile")
 	}
-	msClientSecret, present := os.LookupEnv("MSCLIENTSECRET")
-	if !present {
-		log.Panic("Must set MSCLIENTSECRET")
+	if app.conf.ClientSecret == "" {
+		log.Panic("Must set MSCLIENTSECRET or clientSecret in the profile")
 	}
 
-	// setup configuration for OAuth2
-	app.conf = &oauth2.Config{
-		ClientID:     msClientId,
-		ClientSecret: msClientSecret,
-		Scopes:       []string{"Notes.Read"},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  msAuthURL,
-			TokenURL: msTokenURL,
-		},
-		RedirectURL: myRedirectURL,
+	// ask only for the added scopes, e.g. -add Notes.ReadWrite
+	if *addScopes != "" {
+		// the scopes granted are in the claims of the stored token,
+		// all added scopes are asked for if they are unknown
+		var granted []string
+		if claims, err := profile.Claims(); err == nil {
+			granted = claims.Scopes()
+		}
+
+		conf := profile.Incremental(app.conf, granted,
+			strings.Split(*addScopes, ","))
+		if conf == nil {
+			log.Println("Scopes already granted:", *addScopes)
+			return
+		}
+		app.conf = conf
 	}
 
 	// generate a random token to prevent CSRF attacks
-	app.state = randomToken(32)
+	app.state = auth.RandomState(32)
 
 	// generate URL for user consent for permissions (scopes) above
 	url := app.conf.AuthCodeURL(app.state, oauth2.AccessTypeOffline)
@@ -181,7 +164,7 @@ func main() {
 	}
 
 	// try something
-	const tryURL = "https://graph.microsoft.com/v1.0/me/onenote/notebooks?$select=displayName"
+	tryURL := app.profile.Environment().GraphURL + "/v1.0/me/onenote/notebooks?$select=displayName"
 	fmt.Println("\nGET", tryURL)
 	resp, err := app.client.Get(tryURL)
 	if err != nil {
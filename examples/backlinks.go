@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
+	"github.com/bnixon67/onenote/content"
+	"log"
+	"net/url"
+	"os"
+)
+
+// newClient returns a OneNote client for the named account profile,
+// the access token is set via authorize.go
+func newClient(name string) *onenote.Client {
+	profile, err := auth.LoadProfile(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := profile.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var dot = flag.Bool("dot", false, "write GraphViz DOT instead of JSON")
+var workers = flag.Int("workers", onenote.DefaultWorkers, "number of concurrent `requests`")
+
+// main writes the links between the pages of the notebook or section at
+// the path given as argument, with the pages linking to each page
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: backlinks [-dot] Notebook[/Section]")
+	}
+
+	ctx := context.Background()
+	client := newClient(*profileName)
+
+	// the sections, including those in section groups
+	var sections []onenote.Section
+	walker := onenote.Walker{Client: client, Workers: *workers}
+	err := walker.Walk(ctx, flag.Arg(0), func(node *onenote.Resolved, err error) error {
+		if err != nil {
+			return err
+		}
+		switch node.Kind {
+		case onenote.KindPage:
+			return onenote.SkipAll
+		case onenote.KindSection:
+			// the pages are fetched below, do not list them here
+			sections = append(sections, *node.Section)
+			return onenote.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resolved, err := client.Resolve(ctx, flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var pages *onenote.PageIterator
+	switch resolved.Kind {
+	case onenote.KindNotebook:
+		query := url.Values{}
+		query.Set("$filter", "parentNotebook/id eq '"+resolved.Id+"'")
+		pages = client.Pages(query)
+	case onenote.KindSection:
+		pages = client.SectionPages(resolved.Id, nil)
+	default:
+		log.Fatalf("%s is a %s, not a notebook or section", resolved.Path, resolved.Kind)
+	}
+
+	fetcher := onenote.Fetcher{Client: client, Workers: *workers}
+
+	// all pages are needed to resolve the links before adding them
+	var all []onenote.Page
+	contents := make(map[string]*content.Page)
+	for result := range fetcher.Fetch(ctx, pages) {
+		if result.Err != nil {
+			log.Println(result.Err)
+			continue
+		}
+
+		parsed, err := content.ParseString(result.Content)
+		if err != nil {
+			log.Println(result.Page.Title, err)
+			continue
+		}
+		all = append(all, result.Page)
+		contents[result.Page.Id] = parsed
+	}
+
+	graph := content.NewLinkGraph(all, sections)
+	for _, page := range all {
+		graph.Add(page, contents[page.Id])
+	}
+
+	if *dot {
+		err = graph.WriteDOT(os.Stdout)
+	} else {
+		err = graph.WriteJSON(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
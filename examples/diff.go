@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"github.com/bnixon67/onenote/content"
+	"log"
+	"os"
+)
+
+var asJSON = flag.Bool("json", false, "write JSON instead of a unified diff")
+
+// parseFile returns the page content in the HTML file, such as a snapshot
+// saved from GetPageContent
+func parseFile(name string) *content.Page {
+	file, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	page, err := content.Parse(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return page
+}
+
+// main shows what changed between two snapshots of a page
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		log.Fatal("usage: diff [-json] old.html new.html")
+	}
+
+	diff := content.Diff(parseFile(flag.Arg(0)), parseFile(flag.Arg(1)))
+
+	var err error
+	if *asJSON {
+		err = diff.WriteJSON(os.Stdout)
+	} else {
+		err = diff.WriteText(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,38 +1,34 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/bnixon67/onenote"
-	"golang.org/x/net/html"
-	"io"
-	"io/ioutil"
+	"github.com/bnixon67/onenote/auth"
+	"github.com/bnixon67/onenote/content"
 	"log"
+	"log/slog"
 	"net/url"
 	"os"
-	"strings"
-	"sync"
 	"runtime/pprof"
 	"runtime"
 	"flag"
 )
 
-// readToken reads the access token from the given filename
-func readToken(filename string) []byte {
-	// open file
-	file, err := os.Open(filename)
+// newClient returns a OneNote client for the named account profile,
+// the access token is set via authorize.go
+func newClient(name string) *onenote.Client {
+	profile, err := auth.LoadProfile(name)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	defer file.Close()
 
-	// read file
-	token, err := ioutil.ReadAll(file)
+	client, err := profile.NewClient()
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-
-	return (token)
+	return client
 }
 
 // writeContent writes out the content
@@ -63,47 +59,51 @@ func showResponse(v interface{}) {
 	fmt.Printf("==========\n%s\n===========\n", b)
 }
 
-// find_tag find the given tag in the source using HTML Tokenizer
-//   returns a string array of the tag values
-func find_tag(source io.Reader, tag string) (vals []string) {
-
-	z := html.NewTokenizer(source)
-
-	saveText := false
-	for {
-		tokenType := z.Next()
-
-		switch tokenType {
+// find_tag returns the text of the elements with the given tag
+func find_tag(source string, tag string) (vals []string) {
+	page, err := content.ParseString(source)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
 
-		case html.ErrorToken:
-			return
+	for _, tagged := range page.Tagged(tag) {
+		text := tagged.Text
+		if tagged.Completed() {
+			text = "[x] " + text
+		}
+		vals = append(vals, text)
+	}
+	return vals
+}
 
-		case html.TextToken:
-			if saveText {
-				vals = append(vals, string(z.Text()))
-			}
+// setLevels sets the Level and Order of the pages of one section, which
+// Graph only returns when the pages of the section are listed
+func setLevels(ctx context.Context, client *onenote.Client, pages []onenote.Page) error {
+	levels, err := client.SectionPageLevels(ctx, pages[0].ParentSection.Id, nil)
+	if err != nil {
+		return err
+	}
 
-		case html.StartTagToken:
-			_, hasAttr := z.TagName()
-			if hasAttr {
-				key, val, _ := z.TagAttr()
-				if string(key) == "data-tag" {
-					vals := strings.Split(string(val), ",")
-					for _, v := range vals {
-						if v == tag {
-							saveText = true
-						}
-					}
-				}
-			}
-		case html.EndTagToken:
-			saveText = false
+	byID := make(map[string]onenote.Page)
+	for _, level := range levels {
+		byID[level.Id] = level
+	}
+	for i := range pages {
+		if level, ok := byID[pages[i].Id]; ok {
+			pages[i].Level, pages[i].Order = level.Level, level.Order
 		}
 	}
+	return nil
 }
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile `file`")
 var memprofile = flag.String("memprofile", "", "write memory profile to `file`")
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var logRequests = flag.Bool("log", false, "log requests and responses")
+var workers = flag.Int("workers", onenote.DefaultWorkers, "number of concurrent content `requests`")
+var path = flag.String("path", "UMB Notes", "notebook or section `path` to search, such as Notebook/Section")
+var rate = flag.Float64("rate", 0, "limit to `n` requests per second, 0 for no limit")
 
 func main() {
 //	runtime.GOMAXPROCS(1)
@@ -120,91 +120,116 @@ func main() {
 	       defer pprof.StopCPUProfile()
 	   }
 
-	// read the access token, set via authorize.go
-	token := readToken("token.txt")
-
-	var query url.Values
-	var nextLink *url.URL
+	ctx := context.Background()
+	client := newClient(*profileName)
+	if *logRequests {
+		client.Middleware = append(client.Middleware,
+			onenote.RequestID(), onenote.Logging(slog.Default()))
+	}
+	if *rate > 0 {
+		client.Limiter = onenote.NewLimiter(*rate, *workers)
+	}
 
-	// WaitGroup to fetch multiple pages
-	var wg sync.WaitGroup
+	// find the notebook or section to search
+	resolved, err := client.Resolve(ctx, *path)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// list of page may be returned by multiple queries
-	// @odata.nextLink has the link to next set of pages
-	for {
-		// ----- List Pages
+	query := url.Values{}
+
+	// total number of pages
+	query.Set("$count", "true")
+
+	// sort by page title
+	//			query.Set("$orderby", "parentSection/displayName,title")
+	query.Set("$orderby", "title")
+
+	// exand parentNotebook to get displayName
+	query.Set("$expand", "parentNotebook,parentSection")
+
+	var pages *onenote.PageIterator
+	switch resolved.Kind {
+	case onenote.KindNotebook:
+		// filter on just one Notebook
+		query.Set("$filter", "parentNotebook/id eq '"+resolved.Id+"'")
+		pages = client.Pages(query)
+	case onenote.KindSection:
+		// levels of subpages are only returned for one section
+		query.Set("pagelevel", "true")
+		pages = client.SectionPages(resolved.Id, query)
+	default:
+		log.Fatalf("%s is a %s, not a notebook or section", resolved.Path, resolved.Kind)
+	}
 
-		// first query (no nextLink)
-		if nextLink == nil {
-			query = url.Values{}
+	// fetch page content while the next set of pages is listed
+	fetcher := onenote.Fetcher{
+		Client:  client,
+		Workers: *workers,
+		Ordered: true,
+	}
 
-			// total number of pages
-			query.Set("$count", "true")
+	// pages by section to show subpages below their parent page
+	var sections [][]onenote.Page
+	bySection := make(map[string]int)
+	todos := make(map[string][]string)
 
-			// sort by page title
-			//			query.Set("$orderby", "parentSection/displayName,title")
-			query.Set("$orderby", "title")
+	for result := range fetcher.Fetch(ctx, pages) {
+		if result.Err != nil {
+			log.Println(result.Err)
+			continue
+		}
+		page := result.Page
 
-			// exand parentNotebook to get displayName
-			query.Set("$expand", "parentNotebook,parentSection")
+		// find to-do tags in the page content
+		todos[page.Id] = find_tag(result.Content, "to-do")
 
-			// filter on just one Notebook
-			query.Set("$filter",
-				"parentNotebook/displayName eq 'UMB Notes'")
-		} else {
-			// set query value based on nextLink
-			query = nextLink.Query()
+		n, ok := bySection[page.ParentSection.Id]
+		if !ok {
+			n = len(sections)
+			bySection[page.ParentSection.Id] = n
+			sections = append(sections, nil)
 		}
+		sections[n] = append(sections[n], page)
 
-		// get pages
-		pagesResponse := onenote.ListPages(token, query)
-
-		// get nextLink (if any)
-		nextLink, _ = url.Parse(pagesResponse.ODataNextLink)
-
-		// loop thru each page
-		for _, page := range pagesResponse.Value {
-
-			// increase WaitGroup counter
-			wg.Add(1)
-
-			// run goroutine to get page content and find tags
-			go func(page onenote.Page) {
-				// ensure we decrease WaitGroup
-				defer wg.Done()
-
-				// ----- Get Page Content
-				content := onenote.GetPageContent(token, page.Id, nil)
-
-				// find to-do tags in the page content
-				v := find_tag(strings.NewReader(content), "to-do")
-
-				// at least one to-do tag found
-				if len(v) > 0 {
-					fmt.Printf("----- %3d %s/%s/%s\n",
-						len(v),
-						page.ParentNotebook.DisplayName,
-						page.ParentSection.DisplayName,
-						page.Title)
-						for n, v := range v {
-							fmt.Printf("%3d\t%v\n", n, v)
-						}
-						fmt.Println()
-				}
-			}(page)
-
-			// ----- Write Page Content
-			//writeContent(page.Id+".html", content)
+		// ----- Write Page Content
+		//writeContent(page.Id+".html", result.Content)
+	}
+
+	for _, sectionPages := range sections {
+		// list each section of a notebook again for the levels
+		if resolved.Kind == onenote.KindNotebook {
+			if err := setLevels(ctx, client, sectionPages); err != nil {
+				log.Fatal(err)
+			}
 		}
 
-		// Wait for all page requests complete
-		wg.Wait()
+		onenote.WalkPageTree(onenote.PageTree(sectionPages), func(node *onenote.PageNode) error {
+			v := todos[node.Page.Id]
 
-		// nextLink is empty, so exit loop
-		if pagesResponse.ODataNextLink == "" {
-			break
-		}
+			// at least one to-do tag found
+			if len(v) == 0 {
+				return nil
+			}
+
+			// title with the titles of the parent pages
+			title := node.Page.Title
+			for p := node.Parent; p != nil; p = p.Parent {
+				title = p.Page.Title + "/" + title
+			}
 
+			page := node.Page
+			fmt.Printf("----- %3d %s/%s/%s\n",
+				len(v),
+				page.ParentNotebook.DisplayName,
+				page.ParentSection.DisplayName,
+				title)
+			for n, v := range v {
+				fmt.Printf("%3d\t%v\n", n, v)
+			}
+			fmt.Println()
+			return nil
+		})
 	}
 
 	   if *memprofile != "" {
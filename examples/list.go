@@ -1,33 +1,34 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
 	//	"golang.org/x/net/html"
-	"io/ioutil"
 	"net/url"
 	"os"
 	//	"strings"
 	"log"
+	"log/slog"
+	"time"
 )
 
-// readToken reads the access token from the given filename
-func readToken(filename string) []byte {
-	// open file
-	file, err := os.Open(filename)
+// newClient returns a OneNote client for the named account profile,
+// the access token is set via authorize.go
+func newClient(name string) *onenote.Client {
+	profile, err := auth.LoadProfile(name)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	defer file.Close()
 
-	// read file
-	token, err := ioutil.ReadAll(file)
+	client, err := profile.NewClient()
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-
-	return (token)
+	return client
 }
 
 // writeContent writes out the content
@@ -58,9 +59,33 @@ func showResponse(v interface{}) {
 	fmt.Printf("==========\n%s\n===========\n", b)
 }
 
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var logRequests = flag.Bool("log", false, "log requests and responses")
+var cacheDir = flag.String("cache", "", "cache responses for 5 minutes in `dir`")
+
 func main() {
-	// read the access token, set via authorize.go
-	token := readToken("token.txt")
+	flag.Parse()
+
+	ctx := context.Background()
+	client := newClient(*profileName)
+	if *logRequests {
+		client.Middleware = append(client.Middleware,
+			onenote.RequestID(), onenote.Logging(slog.Default()))
+	}
+	if *cacheDir != "" {
+		client.Cache = onenote.NewCache(*cacheDir, 5*time.Minute)
+	}
+
+	// resolve a path such as "Notebook/Section Group/Section/Page" given
+	// as the argument
+	if flag.NArg() > 0 {
+		resolved, err := client.Resolve(ctx, flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("%s\t%s\t%s\n", resolved.Kind, resolved.Id, resolved.Path)
+		return
+	}
 
 	var query url.Values
 
@@ -69,7 +94,10 @@ func main() {
 	query.Set("$count", "true")
 	//query.Set("$top", "1")
 	//query.Set("$filter", "startswith(displayName, 'U')")
-	notebooksResponse := onenote.ListNotebooks(token, query)
+	notebooksResponse, err := client.ListNotebooks(ctx, query)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	fmt.Printf("total notebooks = %d\n", notebooksResponse.ODataCount)
 	fmt.Printf("response notebooks = %d\n\n", len(notebooksResponse.Value))
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
+	"github.com/bnixon67/onenote/content"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// newClient returns a OneNote client for the named account profile,
+// the access token is set via authorize.go
+func newClient(name string) *onenote.Client {
+	profile, err := auth.LoadProfile(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := profile.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+// fileName returns name without characters that are not allowed in
+// file names
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "untitled"
+	}
+	return name
+}
+
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var outDir = flag.String("out", ".", "write the Markdown and resources to `dir`")
+
+// main exports the page at the path given as argument, such as
+// "Notebook/Section/Page", to Markdown with its images and attachments
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: markdown [-out dir] Notebook/Section/Page")
+	}
+
+	ctx := context.Background()
+	client := newClient(*profileName)
+
+	resolved, err := client.Resolve(ctx, flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resolved.Kind != onenote.KindPage {
+		log.Fatalf("%s is a %s, not a page", resolved.Path, resolved.Kind)
+	}
+
+	html, err := client.GetPageContent(ctx, resolved.Id, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	page, err := content.ParseString(html)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// download the images and attachments next to the Markdown
+	base := fileName(page.Title)
+	links := make(map[string]string)
+	for n, src := range page.Resources() {
+		data, err := client.GetResource(ctx, src)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ext := ".bin"
+		if exts, _ := mime.ExtensionsByType(http.DetectContentType(data)); len(exts) > 0 {
+			ext = exts[0]
+		}
+		name := filepath.Join(base+"_files", fmt.Sprintf("%d%s", n+1, ext))
+
+		path := filepath.Join(*outDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			log.Fatal(err)
+		}
+		links[src] = filepath.ToSlash(name)
+	}
+
+	markdown := page.Markdown(&content.MarkdownOptions{
+		Resource: func(url string) string { return links[url] },
+		Meta: map[string]string{
+			"id":           resolved.Id,
+			"path":         resolved.Path,
+			"lastModified": resolved.Page.LastModifiedDateTime,
+		},
+	})
+
+	path := filepath.Join(*outDir, base+".md")
+	if err := os.WriteFile(path, []byte(markdown), 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(path)
+}
@@ -2,52 +2,34 @@ package main
 
 import (
 	"fmt"
-	"golang.org/x/net/html"
-	"io"
+	"github.com/bnixon67/onenote/content"
+	"log"
 	"os"
-	"strings"
 )
 
-func find_tag(source io.Reader, tag string) {
-
-	z := html.NewTokenizer(source)
-
-	todo := false
-	for {
-		tokenType := z.Next()
-
-		switch tokenType {
-
-		case html.ErrorToken:
-			return
-
-		case html.TextToken:
-			if todo {
-				fmt.Println(string(z.Text()))
-				fmt.Println()
-			}
-
-		case html.StartTagToken:
-			_, hasAttr := z.TagName()
-			if hasAttr {
-				key, val, _ := z.TagAttr()
-				if string(key) == "data-tag" {
-					vals := strings.Split(string(val), ",")
-					for _, v := range vals {
-						if v == tag {
-							todo = true
-						}
-					}
-				}
-			}
-		case html.EndTagToken:
-			todo = false
+// find_tag prints the text of the elements with the given tag
+func find_tag(page *content.Page, tag string) {
+	for _, tagged := range page.Tagged(tag) {
+		state := " "
+		if tagged.Completed() {
+			state = "x"
 		}
+		fmt.Printf("[%s] %s\t%s\n", state, tagged.Text, tagged.Element.Attributes().Target())
+		fmt.Println()
 	}
 }
 
 func main() {
-	file, _ := os.Open(os.Args[1])
+	file, err := os.Open(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	page, err := content.Parse(file)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	find_tag(file, "to-do")
+	find_tag(page, "to-do")
 }
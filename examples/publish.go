@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
+	"github.com/bnixon67/onenote/content/markdown"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// newClient returns a OneNote client for the named account profile,
+// the access token is set via authorize.go
+func newClient(name string) *onenote.Client {
+	profile, err := auth.LoadProfile(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := profile.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var title = flag.String("title", "", "page `title`, the first heading if empty")
+var dryRun = flag.Bool("n", false, "print the HTML instead of creating the page")
+
+// main creates a page from a Markdown file in the section at the path
+// given as argument, such as "Notebook/Section"
+func main() {
+	flag.Parse()
+	if flag.NArg() != 2 {
+		log.Fatal("usage: publish [-title title] [-n] file.md Notebook/Section")
+	}
+	file, path := flag.Arg(0), flag.Arg(1)
+
+	source, err := os.ReadFile(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// images are relative to the Markdown file
+	html, parts, err := markdown.Convert(source, &markdown.Options{
+		Title: *title,
+		Dir:   filepath.Dir(file),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *dryRun {
+		fmt.Print(html)
+		for _, part := range parts {
+			fmt.Printf("part %s: %s, %d bytes\n", part.Name, part.ContentType, len(part.Data))
+		}
+		for _, problem := range onenote.Validate(html, parts) {
+			log.Println(problem)
+		}
+		return
+	}
+
+	ctx := context.Background()
+	client := newClient(*profileName)
+
+	// check the HTML before it is sent
+	client.Strict = true
+
+	resolved, err := client.Resolve(ctx, path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resolved.Kind != onenote.KindSection {
+		log.Fatalf("%s is a %s, not a section", resolved.Path, resolved.Kind)
+	}
+
+	page, err := client.CreatePage(ctx, resolved.Id, html, parts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(page.Title, page.Links.OneNoteWebUrl.Href)
+}
@@ -2,27 +2,26 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
 	"golang.org/x/oauth2"
 	//	"io/ioutil"
 	"html/template"
 	"log"
 	"net/http"
 	"net/url"
-	"os"
 	"os/exec"
 	"runtime"
+	"sync/atomic"
+	"time"
 )
 
-const (
-	msBase        = "https://login.microsoftonline.com/common/oauth2/v2.0"
-	msAuthURL     = msBase + "/authorize"
-	msTokenURL    = msBase + "/token"
-	myRedirectURL = "http://localhost:9999/oauth/callback"
-)
+const myRedirectURL = "http://localhost:9999/oauth/callback"
+
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
 
 // appVars contains shared variables to avoid use of globals
 // TODO - should I use Context instead?
@@ -33,8 +32,34 @@ type appVars struct {
 	state    string
 	authChan chan bool
 	token    *oauth2.Token
+	profile  *auth.Profile
+	breaker  *onenote.Breaker
+	degraded atomic.Bool
 }
 
+// onenoteClient returns a OneNote client for the token and profile,
+// all clients share the circuit breaker
+func (app *appVars) onenoteClient() *onenote.Client {
+	client := onenote.NewClient([]byte(app.token.AccessToken))
+	app.profile.Configure(client)
+	client.Breaker = app.breaker
+	return client
+}
+
+// apiError responds with err from the OneNote API
+func apiError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	if errors.Is(err, onenote.ErrCircuitOpen) {
+		status = http.StatusServiceUnavailable
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// banner is shown at the top of pages while Graph is failing
+const banner = `{{if .Degraded}}<div style="background:#fdd;padding:0.5em">
+	OneNote is not responding normally, results may be incomplete.
+	</div>{{end}}`
+
 func (app *appVars) login(w http.ResponseWriter, r *http.Request) {
 	const tpl = `
 <!DOCTYPE html>
@@ -78,6 +103,7 @@ func (app *appVars) listNotebooks(w http.ResponseWriter, r *http.Request) {
 		<title>{{.Title}}</title>
 	</head>
 	<body>
+	` + banner + `
 	{{range .Notebooks}}<div>{{ .}}</div>{{else}} <div><strong>no rows</strong></div>{{end}}
 	</body>
 </html>`
@@ -89,10 +115,12 @@ func (app *appVars) listNotebooks(w http.ResponseWriter, r *http.Request) {
 
 	data := struct {
 		Title     string
+		Degraded  bool
 		Notebooks []string
 	}{}
 
 	data.Title = "List Notebooks"
+	data.Degraded = app.degraded.Load()
 
 	var query url.Values
 	var nextLink *url.URL
@@ -112,7 +140,11 @@ func (app *appVars) listNotebooks(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// get pages
-		notebooksResponse := onenote.ListNotebooks([]byte(app.token.AccessToken), query)
+		notebooksResponse, err := app.onenoteClient().ListNotebooks(r.Context(), query)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
 
 		// get nextLink (if any)
 		nextLink, _ = url.Parse(notebooksResponse.ODataNextLink)
@@ -148,21 +180,33 @@ func (app *appVars) listPages(w http.ResponseWriter, r *http.Request) {
 		<title>{{.Title}}</title>
 	</head>
 	<body>
-	{{range .Pages}}<div>{{ .}}</div>{{else}} <div><strong>no rows</strong></div>{{end}}
+	` + banner + `
+	{{range .Sections}}<div>{{.Name}}{{template "tree" .Pages}}</div>{{else}} <div><strong>no rows</strong></div>{{end}}
 	</body>
-</html>`
+</html>
+{{define "tree"}}<ul>{{range .}}<li>{{.Page.Title}}{{if .Children}}{{template "tree" .Children}}{{end}}</li>{{end}}</ul>{{end}}`
 
 	t, err := template.New("login").Parse(tpl)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// pages of a section with subpages nested below their parent
+	type sectionPages struct {
+		Name  string
+		Pages []*onenote.PageNode
+		id    string
+	}
+
 	data := struct {
-		Title string
-		Pages []string
+		Title    string
+		Degraded bool
+		Sections []*sectionPages
 	}{}
+	bySection := make(map[string]*sectionPages)
 
 	data.Title = "List Pages"
+	data.Degraded = app.degraded.Load()
 
 	var query url.Values
 	var nextLink *url.URL
@@ -200,7 +244,11 @@ func (app *appVars) listPages(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// get pages
-		pagesResponse := onenote.ListPages([]byte(app.token.AccessToken), query)
+		pagesResponse, err := app.onenoteClient().ListPages(r.Context(), query)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
 
 		// get nextLink (if any)
 		nextLink, _ = url.Parse(pagesResponse.ODataNextLink)
@@ -208,8 +256,15 @@ func (app *appVars) listPages(w http.ResponseWriter, r *http.Request) {
 		// loop thru each page
 		for _, page := range pagesResponse.Value {
 
-			data.Pages = append(data.Pages, fmt.Sprintf("%s/%s/%s", page.ParentNotebook.DisplayName, page.ParentSection.DisplayName, page.Title))
-
+			section := bySection[page.ParentSection.Id]
+			if section == nil {
+				section = &sectionPages{
+					Name: fmt.Sprintf("%s/%s", page.ParentNotebook.DisplayName, page.ParentSection.DisplayName),
+					id:   page.ParentSection.Id,
+				}
+				bySection[page.ParentSection.Id] = section
+				data.Sections = append(data.Sections, section)
+			}
 		}
 
 		// nextLink is empty, so exit loop
@@ -219,6 +274,17 @@ func (app *appVars) listPages(w http.ResponseWriter, r *http.Request) {
 
 	}
 
+	// list the pages of each section again with their levels to show
+	// the subpages, which Graph only returns for the pages of one section
+	for _, section := range data.Sections {
+		pages, err := app.onenoteClient().SectionPageLevels(r.Context(), section.id, nil)
+		if err != nil {
+			apiError(w, err)
+			return
+		}
+		section.Pages = onenote.PageTree(pages)
+	}
+
 	err = t.Execute(w, data)
 	if err != nil {
 		log.Fatal(err)
@@ -287,55 +353,43 @@ func (app *appVars) oauthRedirect(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
-// randomToken returns a securely generated token of size n
-func randomToken(n int) string {
-	// buffer to store n bytes
-	b := make([]byte, n)
-
-	// read random bytes based on size of b
-	_, err := rand.Read(b)
-	if err != nil {
-		log.Panic(err)
-	}
-
-	// convert buffer to URL friendly string
-	return base64.URLEncoding.EncodeToString(b)
-}
-
 // main authorizes via OAuth2 with Microsoft
-// two environmental variables must be set (MSCLIENTID and MSCLIENTSECRET)
+// client id and secret come from the profile or the environment
+// variables MSCLIENTID and MSCLIENTSECRET
 func main() {
+	flag.Parse()
 
 	app := &appVars{}
 
+	// get the account profile
+	profile, err := auth.LoadProfile(*profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	app.profile = profile
+
 	// get top-level context
 	app.ctx = context.Background()
 
-	// get Microsoft client id and secret stored in environment
-	// variables to avoid adding to source code repository
-	msClientId, present := os.LookupEnv("MSCLIENTID")
-	if !present {
-		log.Panic("Must set MSCLIENTID")
-	}
-	msClientSecret, present := os.LookupEnv("MSCLIENTSECRET")
-	if !present {
-		log.Panic("Must set MSCLIENTSECRET")
+	// stop calling Graph during outages and show a banner until it
+	// recovers
+	app.breaker = onenote.NewBreaker(0.5, 30*time.Second)
+	app.breaker.OnStateChange = func(from, to onenote.BreakerState) {
+		log.Printf("circuit %v -> %v", from, to)
+		app.degraded.Store(to != onenote.BreakerClosed)
 	}
 
 	// setup configuration for OAuth2
-	app.conf = &oauth2.Config{
-		ClientID:     msClientId,
-		ClientSecret: msClientSecret,
-		Scopes:       []string{"Notes.Read"},
-		Endpoint: oauth2.Endpoint{
-			AuthURL:  msAuthURL,
-			TokenURL: msTokenURL,
-		},
-		RedirectURL: myRedirectURL,
+	app.conf = profile.Config(myRedirectURL)
+	if app.conf.ClientID == "" {
+		log.Panic("Must set MSCLIENTID or clientId in the profile")
+	}
+	if app.conf.ClientSecret == "" {
+		log.Panic("Must set MSCLIENTSECRET or clientSecret in the profile")
 	}
 
 	// generate a random token to prevent CSRF attacks
-	app.state = randomToken(32)
+	app.state = auth.RandomState(32)
 
 	url := "http://localhost:9999/login"
 
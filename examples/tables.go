@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
+	"github.com/bnixon67/onenote/content"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// newClient returns a OneNote client for the named account profile,
+// the access token is set via authorize.go
+func newClient(name string) *onenote.Client {
+	profile, err := auth.LoadProfile(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := profile.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+// fileName returns name without characters that are not allowed in
+// file names
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "untitled"
+	}
+	return name
+}
+
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var outDir = flag.String("out", ".", "write the tables to `dir`")
+var asJSON = flag.Bool("json", false, "write JSON instead of CSV")
+var workers = flag.Int("workers", onenote.DefaultWorkers, "number of concurrent content `requests`")
+
+// uniqueName returns name, or name with a counter such as "name (2)" if
+// it is in used, and adds the result to used. Names are compared
+// ignoring case for case-insensitive file systems.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for n := 2; used[strings.ToLower(unique)]; n++ {
+		unique = fmt.Sprintf("%s (%d)", name, n)
+	}
+	used[strings.ToLower(unique)] = true
+	return unique
+}
+
+// main writes every table in the pages of the section at the path given
+// as argument, such as "Notebook/Section", to one file per table named
+// after the page and the number of the table on the page. Pages with the
+// same title get a counter after the title.
+func main() {
+	flag.Parse()
+	if flag.NArg() != 1 {
+		log.Fatal("usage: tables [-out dir] [-json] Notebook/Section")
+	}
+
+	ctx := context.Background()
+	client := newClient(*profileName)
+
+	resolved, err := client.Resolve(ctx, flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resolved.Kind != onenote.KindSection {
+		log.Fatalf("%s is a %s, not a section", resolved.Path, resolved.Kind)
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		log.Fatal(err)
+	}
+
+	ext := ".csv"
+	if *asJSON {
+		ext = ".json"
+	}
+
+	fetcher := onenote.Fetcher{
+		Client:  client,
+		Workers: *workers,
+		Ordered: true,
+	}
+
+	// the page names used so far
+	used := make(map[string]bool)
+
+	for result := range fetcher.Fetch(ctx, client.SectionPages(resolved.Id, nil)) {
+		if result.Err != nil {
+			log.Println(result.Err)
+			continue
+		}
+
+		page, err := content.ParseString(result.Content)
+		if err != nil {
+			log.Println(result.Page.Title, err)
+			continue
+		}
+
+		tables := page.Tables()
+		if len(tables) == 0 {
+			continue
+		}
+		base := uniqueName(fileName(result.Page.Title), used)
+
+		for n, table := range tables {
+			name := filepath.Join(*outDir, fmt.Sprintf("%s-%d%s", base, n+1, ext))
+
+			file, err := os.Create(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			if *asJSON {
+				err = table.WriteJSON(file)
+			} else {
+				err = table.WriteCSV(file)
+			}
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Println(name)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var warnWithin = flag.Duration("warn", 10*time.Minute, "warn if the token expires within `duration`")
+
+// main shows the claims of the stored access token of a profile
+// to diagnose failing calls, the token signature is not verified
+func main() {
+	flag.Parse()
+
+	profile, err := auth.LoadProfile(*profileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("token store\t%s\n", profile.TokenStore)
+
+	claims, err := profile.Claims()
+	if errors.Is(err, onenote.ErrOpaqueToken) {
+		fmt.Println("token is opaque (personal account), claims not available")
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	expiry := claims.Expiry()
+	fmt.Printf("user\t\t%s\n", claims.UserPrincipalName())
+	fmt.Printf("tenant\t\t%s\n", claims.TenantID)
+	fmt.Printf("audience\t%s\n", claims.Audience)
+	fmt.Printf("scopes\t\t%s\n", strings.Join(claims.Scopes(), " "))
+	fmt.Printf("issued\t\t%s\n", time.Unix(claims.IssuedAt, 0).Format(time.RFC3339))
+	fmt.Printf("expires\t\t%s\n", expiry.Format(time.RFC3339))
+
+	// collect problems that make calls fail
+	var warnings []string
+
+	switch {
+	case claims.Expired(time.Now()):
+		warnings = append(warnings, fmt.Sprintf("token expired %s ago",
+			time.Since(expiry).Round(time.Second)))
+	case claims.ExpiresWithin(time.Now(), *warnWithin):
+		warnings = append(warnings, fmt.Sprintf("token expires in %s",
+			time.Until(expiry).Round(time.Second)))
+	}
+
+	if !claims.IsGraphAudience() {
+		warnings = append(warnings, "token is not for Microsoft Graph")
+	}
+
+	// only a tenant id can be compared, not a domain name or alias
+	tenant := profile.TenantOrDefault()
+	if len(tenant) == 36 && strings.Count(tenant, "-") == 4 &&
+		!strings.EqualFold(tenant, claims.TenantID) {
+		warnings = append(warnings, "token is for tenant "+
+			claims.TenantID+", profile expects "+tenant)
+	}
+
+	owner := profile.Owner
+	if owner == "" {
+		owner = onenote.DefaultOwner
+	}
+	if !onenote.Allowed(claims.Scopes(), onenote.OpListPages, owner) {
+		required := onenote.RequiredScope(onenote.OpListPages, owner)
+		warnings = append(warnings, "token lacks scope "+string(required))
+	}
+
+	for _, w := range warnings {
+		fmt.Println("WARNING:", w)
+	}
+	if len(warnings) > 0 {
+		os.Exit(1)
+	}
+}
@@ -1,33 +1,33 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
+	"github.com/bnixon67/onenote/content"
 	//	"golang.org/x/net/html"
-	"io/ioutil"
 	"net/url"
 	"os"
 	//	"strings"
 	"log"
 )
 
-// readToken reads the access token from the given filename
-func readToken(filename string) []byte {
-	// open file
-	file, err := os.Open(filename)
+// newClient returns a OneNote client for the named account profile,
+// the access token is set via authorize.go
+func newClient(name string) *onenote.Client {
+	profile, err := auth.LoadProfile(name)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	defer file.Close()
 
-	// read file
-	token, err := ioutil.ReadAll(file)
+	client, err := profile.NewClient()
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-
-	return (token)
+	return client
 }
 
 // writeContent writes out the content
@@ -58,9 +58,13 @@ func showResponse(v interface{}) {
 	fmt.Printf("==========\n%s\n===========\n", b)
 }
 
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+
 func main() {
-	// read the access token, set via authorize.go
-	token := readToken("token.txt")
+	flag.Parse()
+
+	ctx := context.Background()
+	client := newClient(*profileName)
 
 	var query url.Values
 
@@ -69,7 +73,10 @@ func main() {
 	query.Set("$count", "true")
 	query.Set("$top", "1")
 	//query.Set("$filter", "startswith(displayName, 'U')")
-	notebooksResponse := onenote.ListNotebooks(token, query)
+	notebooksResponse, err := client.ListNotebooks(ctx, query)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	fmt.Printf("total notebooks = %d\n", notebooksResponse.ODataCount)
 	fmt.Printf("response notebooks = %d\n\n", len(notebooksResponse.Value))
@@ -85,7 +92,10 @@ func main() {
 	query.Set("$count", "true")
 	query.Set("$top", "5")
 	query.Set("$expand", "parentNotebook")
-	pagesResponse := onenote.ListPages(token, query)
+	pagesResponse, err := client.ListPages(ctx, query)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	fmt.Printf("count of pages = %d\n", pagesResponse.ODataCount)
 	fmt.Printf("pages in response = %d\n\n", len(pagesResponse.Value))
@@ -95,10 +105,17 @@ func main() {
 		fmt.Printf("\t%s\n", page.ParentNotebook.DisplayName)
 
 		// ----- Get Page Content
-		content := onenote.GetPageContent(token, page.Id, nil)
-
-		// ----- Write Page Content
-		writeContent(page.Id+".html", content)
+		html, err := client.GetPageContent(ctx, page.Id, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// ----- Write Page Text
+		parsed, err := content.ParseString(html)
+		if err != nil {
+			log.Fatal(err)
+		}
+		writeContent(page.Id+".txt", parsed.Text(nil)+"\n")
 
 	}
 	fmt.Println()
@@ -107,7 +124,10 @@ func main() {
 	// ----- Get Page
 	query = url.Values{}
 	query.Set("$expand", "parentNotebook")
-	page := onenote.GetPage(token, "0-f3fdcfcce6b22f030269699e4d557d1b!1-16BE860D241E39E5!11720", query)
+	page, err := client.GetPage(ctx, "0-f3fdcfcce6b22f030269699e4d557d1b!1-16BE860D241E39E5!11720", query)
+	if err != nil {
+		log.Fatal(err)
+	}
 	fmt.Printf("id=%v\n", page.Id)
 	fmt.Printf("title=%v\n", page.Title)
 	fmt.Printf("link=%v\n", page.Links.OneNoteWebUrl.Href)
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/auth"
+	"log"
+	"strings"
+)
+
+// newClient returns a OneNote client for the named account profile,
+// the access token is set via authorize.go
+func newClient(name string) *onenote.Client {
+	profile, err := auth.LoadProfile(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client, err := profile.NewClient()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+var profileName = flag.String("profile", "", "account `profile` from profiles.json")
+var noPages = flag.Bool("nopages", false, "do not list the pages of sections")
+var workers = flag.Int("workers", onenote.DefaultWorkers, "number of concurrent list `requests`")
+
+// main prints the notebook hierarchy below the path given as argument,
+// or of all notebooks
+func main() {
+	flag.Parse()
+
+	ctx := context.Background()
+	client := newClient(*profileName)
+
+	walker := onenote.Walker{Client: client, Workers: *workers}
+	err := walker.Walk(ctx, flag.Arg(0), func(node *onenote.Resolved, err error) error {
+		if err != nil {
+			log.Printf("%s: %v", node.Path, err)
+			return nil
+		}
+
+		names := onenote.SplitPath(node.Path)
+		fmt.Printf("%s%s (%s)\n", strings.Repeat("  ", len(names)-1),
+			names[len(names)-1], node.Kind)
+
+		if *noPages && node.Kind == onenote.KindSection {
+			return onenote.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,184 @@
+package onenote
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+// DefaultWorkers is the number of workers of a Fetcher by default
+const DefaultWorkers = 4
+
+// ContentResult is the content of one page fetched by a Fetcher
+type ContentResult struct {
+	// Index is the position of the page in the iterator
+	Index int
+
+	// Page is the page from the iterator
+	Page Page
+
+	// Content is the HTML content of the page
+	Content string
+
+	// Err is the error fetching the content, or the error of the
+	// iterator in the last result
+	Err error
+}
+
+// Fetcher fetches the content of pages with a bounded number of workers
+// while the iterator requests further pages in the background
+type Fetcher struct {
+	Client *Client
+
+	// Workers is the number of concurrent content requests,
+	// DefaultWorkers if zero
+	Workers int
+
+	// RequestsPerSecond limits the content requests of all workers,
+	// unlimited if zero. Use Client.Limiter to limit all requests.
+	RequestsPerSecond float64
+
+	// Ordered returns results in iterator order, otherwise results are
+	// returned as soon as they are ready. Pages are fetched at most
+	// twice Workers ahead of the next result, so a slow page holds back
+	// a bounded number of results.
+	Ordered bool
+
+	// Query is passed to GetPageContent
+	Query url.Values
+}
+
+// job is a page to fetch
+type job struct {
+	index int
+	page  Page
+}
+
+// Fetch returns a channel with the content of each page of it. The
+// channel is closed after the last page, or after an iterator error
+// which is returned as a result with Err set. Cancel ctx to stop early,
+// the channel must still be read until it is closed.
+func (f *Fetcher) Fetch(ctx context.Context, it *PageIterator) <-chan ContentResult {
+	workers := f.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	jobs := make(chan job, workers)
+	results := make(chan ContentResult, workers)
+
+	// in order, a slot is taken for each job until its result is
+	// returned, limiting the results waiting for an earlier one
+	var slots chan struct{}
+	if f.Ordered {
+		slots = make(chan struct{}, 2*workers)
+	}
+
+	// page through the iterator in the background
+	var iterErr error
+	var total int
+	go func() {
+		defer close(jobs)
+		for i := 0; ; i++ {
+			page, err := it.Next(ctx)
+			if err != nil {
+				if err != Done {
+					iterErr = err
+				}
+				total = i
+				return
+			}
+
+			if slots != nil {
+				select {
+				case slots <- struct{}{}:
+				case <-ctx.Done():
+					iterErr = ctx.Err()
+					total = i
+					return
+				}
+			}
+
+			select {
+			case jobs <- job{index: i, page: page}:
+			case <-ctx.Done():
+				iterErr = ctx.Err()
+				total = i
+				return
+			}
+		}
+	}()
+
+	// limit the rate of all workers together
+	var limiter *Limiter
+	if f.RequestsPerSecond > 0 {
+		limiter = NewLimiter(f.RequestsPerSecond, 1)
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				r := ContentResult{Index: j.index, Page: j.page}
+				if err := limiter.Wait(ctx, 1); err != nil {
+					r.Err = err
+				} else if err := ctx.Err(); err != nil {
+					r.Err = err
+				} else {
+					r.Content, r.Err = f.Client.GetPageContent(ctx, j.page.Id, f.Query)
+				}
+				results <- r
+			}
+		}()
+	}
+
+	// close results when the workers are done, the jobs channel is
+	// closed after total and iterErr are set
+	go func() {
+		wg.Wait()
+		if iterErr != nil {
+			results <- ContentResult{Index: total, Err: iterErr}
+		}
+		close(results)
+	}()
+
+	if !f.Ordered {
+		return results
+	}
+	return reorder(results, slots)
+}
+
+// reorder returns the results in order of Index, giving back the slot of
+// each result once returned
+func reorder(in <-chan ContentResult, slots chan struct{}) <-chan ContentResult {
+	out := make(chan ContentResult)
+
+	go func() {
+		defer close(out)
+
+		pending := make(map[int]ContentResult)
+		next := 0
+		for r := range in {
+			pending[r.Index] = r
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				out <- r
+				next++
+
+				// the iterator error has no slot
+				select {
+				case <-slots:
+				default:
+				}
+			}
+		}
+	}()
+
+	return out
+}
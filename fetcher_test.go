@@ -0,0 +1,181 @@
+package onenote_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+// newFetchServer returns a server with n pages in one section, the body
+// of page i is <p>i</p>
+func newFetchServer(t *testing.T, n int) (*onenotetest.Server, []onenote.Page) {
+	t.Helper()
+	srv := onenotetest.NewServer()
+	t.Cleanup(srv.Close)
+	srv.PageSize = 3
+
+	sec := srv.AddSection(srv.AddNotebook("Work").Id, "Notes")
+	var pages []onenote.Page
+	for i := 0; i < n; i++ {
+		pages = append(pages, srv.AddPage(sec.Id, fmt.Sprintf("Page %02d", i), fmt.Sprintf("<p>%d</p>", i)))
+	}
+	return srv, pages
+}
+
+// collect reads all results, failing if the channel is not closed in time
+func collect(t *testing.T, results <-chan onenote.ContentResult) []onenote.ContentResult {
+	t.Helper()
+	var all []onenote.ContentResult
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return all
+			}
+			all = append(all, r)
+		case <-timeout:
+			t.Fatalf("results not closed after %d", len(all))
+		}
+	}
+}
+
+func TestFetcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		ordered bool
+		workers int
+	}{
+		{"ordered", true, 3},
+		{"ordered one worker", true, 1},
+		{"unordered", false, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, pages := newFetchServer(t, 10)
+			f := onenote.Fetcher{Client: srv.Client(), Workers: tt.workers, Ordered: tt.ordered}
+
+			results := collect(t, f.Fetch(context.Background(), srv.Client().Pages(nil)))
+			if len(results) != len(pages) {
+				t.Fatalf("got %d results, want %d", len(results), len(pages))
+			}
+
+			seen := make(map[int]bool)
+			for i, r := range results {
+				if r.Err != nil {
+					t.Fatalf("result %d error = %v", r.Index, r.Err)
+				}
+				if tt.ordered && r.Index != i {
+					t.Errorf("result %d has index %d", i, r.Index)
+				}
+				if !strings.Contains(r.Content, fmt.Sprintf("<p>%d</p>", r.Index)) || r.Page.Id != pages[r.Index].Id {
+					t.Errorf("result %d is page %q with %q", r.Index, r.Page.Title, r.Content)
+				}
+				seen[r.Index] = true
+			}
+			if len(seen) != len(pages) {
+				t.Errorf("got %d distinct pages, want %d", len(seen), len(pages))
+			}
+		})
+	}
+}
+
+func TestFetcherErrors(t *testing.T) {
+	srv, pages := newFetchServer(t, 5)
+	srv.InjectFault(onenotetest.Fault{Path: "/pages/" + pages[2].Id + "/content", Status: http.StatusNotFound})
+
+	f := onenote.Fetcher{Client: srv.Client(), Workers: 2, Ordered: true}
+	results := collect(t, f.Fetch(context.Background(), srv.Client().Pages(nil)))
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5", len(results))
+	}
+	for i, r := range results {
+		var apiErr *onenote.Error
+		if failed := errors.As(r.Err, &apiErr); failed != (i == 2) {
+			t.Errorf("result %d error = %v", i, r.Err)
+		}
+	}
+
+	// an iterator error is the last result
+	srv.ClearFaults()
+	srv.InjectFault(onenotetest.Fault{Method: http.MethodGet, Path: "/onenote/pages", Status: http.StatusBadRequest, Times: 1})
+	results = collect(t, f.Fetch(context.Background(), srv.Client().Pages(nil)))
+	if len(results) != 1 || results[0].Err == nil || results[0].Index != 0 {
+		t.Errorf("results = %+v, want one iterator error", results)
+	}
+}
+
+func TestFetcherCancel(t *testing.T) {
+	srv, _ := newFetchServer(t, 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f := onenote.Fetcher{Client: srv.Client(), Workers: 2, Ordered: true}
+	results := f.Fetch(ctx, srv.Client().Pages(nil))
+	first := <-results
+	cancel()
+
+	rest := collect(t, results)
+	if first.Err != nil {
+		t.Fatalf("first result error = %v", first.Err)
+	}
+	if len(rest) == 0 || !errors.Is(rest[len(rest)-1].Err, context.Canceled) {
+		t.Errorf("last of %d results error = %v, want context.Canceled", len(rest), rest[len(rest)-1:])
+	}
+	if len(rest) >= 19 {
+		t.Errorf("got %d more results after cancel, want fewer than 19", len(rest))
+	}
+}
+
+func TestFetcherWindow(t *testing.T) {
+	srv, pages := newFetchServer(t, 30)
+
+	// hold the content of the first page
+	var started int32
+	release := make(chan struct{})
+	var once sync.Once
+	defer once.Do(func() { close(release) })
+
+	client := srv.Client()
+	client.Middleware = append(client.Middleware, func(next http.RoundTripper) http.RoundTripper {
+		return onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/content") {
+				atomic.AddInt32(&started, 1)
+				if strings.Contains(req.URL.Path, pages[0].Id) {
+					<-release
+				}
+			}
+			return next.RoundTrip(req)
+		})
+	})
+
+	const workers = 3
+	f := onenote.Fetcher{Client: client, Workers: workers, Ordered: true}
+	results := f.Fetch(context.Background(), client.Pages(nil))
+
+	// the pages after the first are fetched up to the window
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&started) < 2*workers && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := atomic.LoadInt32(&started); got != 2*workers {
+		t.Errorf("started %d content requests while the first is held, want %d", got, 2*workers)
+	}
+
+	once.Do(func() { close(release) })
+	all := collect(t, results)
+	if len(all) != len(pages) {
+		t.Errorf("got %d results, want %d", len(all), len(pages))
+	}
+}
@@ -0,0 +1,98 @@
+package onenote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Done is returned by iterators when there are no more items
+var Done = errors.New("onenote: no more items")
+
+// PageIterator returns the pages of a list one at a time, requesting the
+// next set with @odata.nextLink as needed
+type PageIterator struct {
+	c     *Client
+	url   string
+	query url.Values
+
+	// Count is @odata.count from the first response, if requested
+	Count int
+
+	buf     []Page
+	next    string
+	started bool
+	err     error
+}
+
+// Pages returns an iterator over the pages matching query
+func (c *Client) Pages(query url.Values) *PageIterator {
+	return &PageIterator{c: c, url: c.ownerURL() + "/pages", query: query}
+}
+
+// SectionPages returns an iterator over the pages of the section
+func (c *Client) SectionPages(sectionID string, query url.Values) *PageIterator {
+	return &PageIterator{c: c, url: c.ownerURL() + "/sections/" + sectionID + "/pages", query: query}
+}
+
+// Next returns the next page, or Done when there are no more pages
+func (it *PageIterator) Next(ctx context.Context) (Page, error) {
+	for len(it.buf) == 0 {
+		if it.err != nil {
+			return Page{}, it.err
+		}
+		if it.started && it.next == "" {
+			it.err = Done
+			return Page{}, Done
+		}
+		it.fetch(ctx)
+	}
+
+	page := it.buf[0]
+	it.buf = it.buf[1:]
+	return page, nil
+}
+
+// fetch requests the first or next set of pages
+func (it *PageIterator) fetch(ctx context.Context) {
+	r := request{op: OpListPages, method: http.MethodGet, url: it.url, query: it.query}
+	if it.started {
+		// nextLink already holds the query
+		r.url, r.query = it.next, nil
+	}
+
+	var response PageResponse
+	if err := it.c.doJSON(ctx, r, &response); err != nil {
+		it.err = err
+		return
+	}
+
+	if !it.started {
+		it.Count = response.ODataCount
+	}
+	it.started = true
+	it.buf = response.Value
+	it.next = response.ODataNextLink
+}
+
+// All returns the remaining pages
+func (it *PageIterator) All(ctx context.Context) ([]Page, error) {
+	var pages []Page
+	for {
+		page, err := it.Next(ctx)
+		if err == Done {
+			return pages, nil
+		}
+		if err != nil {
+			return pages, err
+		}
+		pages = append(pages, page)
+	}
+}
+
+// isAbsolute reports whether ref is an absolute http or https URL
+func isAbsolute(ref string) bool {
+	return strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://")
+}
@@ -0,0 +1,246 @@
+package onenote
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket that limits the rate of requests to Graph.
+// One Limiter may be shared by goroutines and by Clients that count
+// against the same app or user limits. After a 429 response the rate is
+// halved and requests wait for Retry-After, the rate then recovers by
+// doubling after each RecoverAfter without throttling.
+type Limiter struct {
+	// Rate is the number of requests per second
+	Rate float64
+
+	// Burst is the number of requests allowed at once
+	Burst int
+
+	// MinRate is the lowest rate after throttling, Rate/16 if zero
+	MinRate float64
+
+	// RecoverAfter is the time without throttling before the rate is
+	// doubled again, DefaultRecoverAfter if zero
+	RecoverAfter time.Duration
+
+	mu        sync.Mutex
+	tokens    float64
+	last      time.Time
+	rate      float64
+	pauseTill time.Time
+	recoverAt time.Time
+}
+
+// DefaultRecoverAfter is the default Limiter.RecoverAfter
+const DefaultRecoverAfter = 30 * time.Second
+
+// DefaultRetryAfter is the wait after a 429 response without Retry-After
+const DefaultRetryAfter = 5 * time.Second
+
+// NewLimiter returns a Limiter allowing rate requests per second
+// and bursts of burst requests
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{Rate: rate, Burst: burst}
+}
+
+// advance adds the tokens earned since the last call and recovers the
+// rate, the caller must hold l.mu
+func (l *Limiter) advance(now time.Time) {
+	burst := float64(l.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	if l.last.IsZero() {
+		l.tokens = burst
+		l.last = now
+	}
+	if l.rate == 0 || l.rate > l.Rate {
+		l.rate = l.Rate
+	}
+
+	// recover towards the configured rate
+	for l.rate < l.Rate && !l.recoverAt.IsZero() && !now.Before(l.recoverAt) {
+		l.rate *= 2
+		if l.rate > l.Rate {
+			l.rate = l.Rate
+		}
+		l.recoverAt = l.recoverAt.Add(l.recoverAfter())
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+}
+
+// recoverAfter returns RecoverAfter or its default
+func (l *Limiter) recoverAfter() time.Duration {
+	if l.RecoverAfter > 0 {
+		return l.RecoverAfter
+	}
+	return DefaultRecoverAfter
+}
+
+// Wait blocks until n requests are allowed or ctx is done. A weight
+// larger than Burst is allowed and delays the requests that follow.
+// A nil Limiter allows all requests.
+func (l *Limiter) Wait(ctx context.Context, n int) error {
+	if l == nil || l.Rate <= 0 || n <= 0 {
+		return nil
+	}
+
+	// reserve the tokens now and sleep outside the lock, so waiters are
+	// served in order
+	l.mu.Lock()
+	now := time.Now()
+	l.advance(now)
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if pause := l.pauseTill.Sub(now); pause > delay {
+		delay = pause
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give back the reservation
+		l.mu.Lock()
+		l.tokens += float64(n)
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Throttled lowers the rate after a 429 response and pauses all requests
+// for retryAfter
+func (l *Limiter) Throttled(retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.advance(now)
+
+	minRate := l.MinRate
+	if minRate <= 0 {
+		minRate = l.Rate / 16
+	}
+	l.rate /= 2
+	if l.rate < minRate {
+		l.rate = minRate
+	}
+	l.recoverAt = now.Add(l.recoverAfter())
+
+	if retryAfter <= 0 {
+		retryAfter = DefaultRetryAfter
+	}
+	if till := now.Add(retryAfter); till.After(l.pauseTill) {
+		l.pauseTill = till
+	}
+
+	// no burst right after the pause
+	if l.tokens > 0 {
+		l.tokens = 0
+	}
+}
+
+// CurrentRate returns the rate in requests per second after throttling
+func (l *Limiter) CurrentRate() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(time.Now())
+	return l.rate
+}
+
+// Middleware returns a Middleware that waits for the Limiter before each
+// request and calls Throttled on 429 responses. A $batch request counts
+// as the number of requests in its body.
+func (l *Limiter) Middleware() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := l.Wait(req.Context(), requestWeight(req)); err != nil {
+				return nil, waitError{err}
+			}
+
+			resp, err := next.RoundTrip(req)
+			if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+				l.Throttled(RetryAfter(resp.Header))
+			}
+			return resp, err
+		})
+	}
+}
+
+// waitError is an error of Wait in the Middleware, the request was not
+// sent so a Breaker does not count it
+type waitError struct {
+	err error
+}
+
+func (e waitError) Error() string { return e.err.Error() }
+
+func (e waitError) Unwrap() error { return e.err }
+
+// requestWeight returns the number of requests sent by req,
+// the sub-requests for a JSON $batch request and one otherwise
+func requestWeight(req *http.Request) int {
+	if !strings.HasSuffix(req.URL.Path, "/$batch") || req.GetBody == nil {
+		return 1
+	}
+
+	// read a copy so the body is still sent
+	body, err := req.GetBody()
+	if err != nil {
+		return 1
+	}
+	defer body.Close()
+
+	var batch struct {
+		Requests []json.RawMessage `json:"requests"`
+	}
+	b, err := io.ReadAll(body)
+	if err != nil || json.Unmarshal(b, &batch) != nil || len(batch.Requests) == 0 {
+		return 1
+	}
+	return len(batch.Requests)
+}
+
+// RetryAfter returns the wait requested by the Retry-After header in
+// seconds or as an HTTP date, zero if not set
+func RetryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,210 @@
+package onenote_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+)
+
+func TestLimiterWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		limiter  *onenote.Limiter
+		weights  []int
+		wantWait time.Duration
+	}{
+		{"nil", nil, []int{1, 1, 1}, 0},
+		{"no rate", &onenote.Limiter{}, []int{1, 1, 1}, 0},
+		{"within burst", onenote.NewLimiter(10, 3), []int{1, 1, 1}, 0},
+		{"past burst", onenote.NewLimiter(10, 2), []int{1, 1, 1}, 100 * time.Millisecond},
+		{"weight", onenote.NewLimiter(10, 2), []int{2}, 0},
+		{"weight past burst", onenote.NewLimiter(10, 2), []int{3}, 100 * time.Millisecond},
+		{"after weight", onenote.NewLimiter(10, 2), []int{3, 1}, 200 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			for _, n := range tt.weights {
+				if err := tt.limiter.Wait(context.Background(), n); err != nil {
+					t.Fatal(err)
+				}
+			}
+			elapsed := time.Since(start)
+			if elapsed < tt.wantWait-10*time.Millisecond || elapsed > tt.wantWait+80*time.Millisecond {
+				t.Errorf("waited %v, want about %v", elapsed, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestLimiterWaitCanceled(t *testing.T) {
+	l := onenote.NewLimiter(1, 1)
+	ctx := context.Background()
+	if err := l.Wait(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(short, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// the canceled reservation is given back, so the next request waits
+	// for one token and not two
+	start := time.Now()
+	if err := l.Wait(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 1500*time.Millisecond {
+		t.Errorf("waited %v after a canceled Wait, want under a second", elapsed)
+	}
+}
+
+func TestLimiterThrottled(t *testing.T) {
+	tests := []struct {
+		name     string
+		minRate  float64
+		throttle int
+		wantRate float64
+	}{
+		{"none", 0, 0, 16},
+		{"once", 0, 1, 8},
+		{"twice", 0, 2, 4},
+		{"default floor", 0, 10, 1},
+		{"min rate", 6, 2, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &onenote.Limiter{Rate: 16, Burst: 1, MinRate: tt.minRate, RecoverAfter: time.Hour}
+			for i := 0; i < tt.throttle; i++ {
+				l.Throttled(time.Millisecond)
+			}
+			if got := l.CurrentRate(); got != tt.wantRate {
+				t.Errorf("CurrentRate() = %v, want %v", got, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestLimiterRecover(t *testing.T) {
+	l := &onenote.Limiter{Rate: 16, Burst: 1, RecoverAfter: 20 * time.Millisecond}
+	l.Throttled(time.Millisecond)
+	l.Throttled(time.Millisecond)
+	if got := l.CurrentRate(); got != 4 {
+		t.Fatalf("CurrentRate() = %v, want 4", got)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got := l.CurrentRate(); got != 16 {
+		t.Errorf("CurrentRate() after recovery = %v, want 16", got)
+	}
+}
+
+func TestLimiterMiddleware(t *testing.T) {
+	batch := func(n int) string {
+		return `{"requests":[` + strings.TrimSuffix(strings.Repeat(`{"id":"1"},`, n), ",") + `]}`
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		status   int
+		wantWait bool
+		wantRate float64
+	}{
+		{"request", "/me/onenote/pages", "", http.StatusOK, false, 10},
+		{"small batch", "/$batch", batch(2), http.StatusOK, false, 10},
+		{"batch over burst", "/$batch", batch(4), http.StatusOK, true, 10},
+		{"not json", "/$batch", "x", http.StatusOK, false, 10},
+		{"throttled", "/me/onenote/pages", "", http.StatusTooManyRequests, true, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a slow rate so the next wait is much longer than the timeout
+			l := &onenote.Limiter{Rate: 10, Burst: 3, RecoverAfter: time.Hour}
+
+			var sent string
+			transport := onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				if req.Body != nil {
+					b, _ := io.ReadAll(req.Body)
+					sent = string(b)
+				}
+				header := http.Header{}
+				if tt.status == http.StatusTooManyRequests {
+					header.Set("Retry-After", "1")
+				}
+				return &http.Response{StatusCode: tt.status, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+			})
+
+			method := http.MethodGet
+			if tt.body != "" {
+				method = http.MethodPost
+			}
+			req, err := http.NewRequest(method, "https://graph.microsoft.com/v1.0"+tt.path, bytes.NewReader([]byte(tt.body)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := onenote.Chain(transport, l.Middleware()).RoundTrip(req); err != nil {
+				t.Fatal(err)
+			}
+			if sent != tt.body {
+				t.Errorf("sent body %q, want %q", sent, tt.body)
+			}
+			if got := l.CurrentRate(); got != tt.wantRate {
+				t.Errorf("CurrentRate() = %v, want %v", got, tt.wantRate)
+			}
+
+			// the next request waits if the first used more than the burst
+			// or was throttled
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+			defer cancel()
+			err = l.Wait(ctx, 1)
+			if waited := err != nil; waited != tt.wantWait {
+				t.Errorf("next Wait() error = %v, want waiting %v", err, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			h := http.Header{}
+			if tt.value != "" {
+				h.Set("Retry-After", tt.value)
+			}
+			if got := onenote.RetryAfter(h); got != tt.want {
+				t.Errorf("RetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	h := http.Header{"Retry-After": {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}
+	if got := onenote.RetryAfter(h); got <= 58*time.Second || got > time.Minute {
+		t.Errorf("RetryAfter(date) = %v, want about a minute", got)
+	}
+}
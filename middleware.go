@@ -0,0 +1,183 @@
+package onenote
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps a RoundTripper to observe or change the requests
+// made by a Client
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain wraps rt with the middlewares, the first middleware sees the
+// request first and the response last
+func Chain(rt http.RoundTripper, middlewares ...Middleware) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		rt = middlewares[i](rt)
+	}
+	return rt
+}
+
+// httpClient returns the HTTP client used to execute requests,
+// with the middlewares of the Client around its transport, then the
+// Breaker, so an open circuit does not wait for the Limiter, and the
+// Limiter closest to the transport. The Breaker does not count requests
+// that fail while waiting for the Limiter.
+func (c *Client) httpClient() *http.Client {
+	base := c.HTTPClient
+	if base == nil {
+		base = http.DefaultClient
+	}
+
+	middlewares := c.Middleware[:len(c.Middleware):len(c.Middleware)]
+	if c.Breaker != nil {
+		middlewares = append(middlewares, c.Breaker.Middleware())
+	}
+	if c.Limiter != nil {
+		middlewares = append(middlewares, c.Limiter.Middleware())
+	}
+
+	if len(middlewares) == 0 {
+		return base
+	}
+
+	client := *base
+	client.Transport = Chain(base.Transport, middlewares...)
+	return &client
+}
+
+// Headers used to correlate requests with Microsoft Graph
+const (
+	ClientRequestIDHeader = "client-request-id"
+	RequestIDHeader       = "request-id"
+)
+
+// newRequestID returns a random version 4 UUID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// RequestID sets a client-request-id header on each request that does not
+// have one, and asks Graph to return it in the response
+func RequestID() Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(ClientRequestIDHeader) == "" {
+				// RoundTrippers must not modify the request
+				req = req.Clone(req.Context())
+				req.Header.Set(ClientRequestIDHeader, newRequestID())
+				req.Header.Set("return-client-request-id", "true")
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// RedactHeaders returns a copy of h with credentials replaced
+func RedactHeaders(h http.Header) http.Header {
+	result := h.Clone()
+	for _, key := range []string{"Authorization", "Cookie", "Set-Cookie"} {
+		if result.Get(key) != "" {
+			result.Set(key, "REDACTED")
+		}
+	}
+	return result
+}
+
+// TimingFunc receives the duration of each request,
+// resp is nil if err is not nil
+type TimingFunc func(req *http.Request, resp *http.Response, err error, d time.Duration)
+
+// Timing calls fn with the duration of each request, for example to
+// record metrics
+func Timing(fn TimingFunc) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			fn(req, resp, err, time.Since(start))
+			return resp, err
+		})
+	}
+}
+
+// Logging logs each request and response with logger. The request is
+// logged at debug level with redacted headers, the response at info
+// level, or warn level for errors, with the status, duration, the
+// client-request-id and the request-id returned by Graph.
+// Use it after RequestID to log the client-request-id.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := req.Context()
+			clientRequestID := req.Header.Get(ClientRequestIDHeader)
+
+			if logger.Enabled(ctx, slog.LevelDebug) {
+				logger.DebugContext(ctx, "onenote request",
+					"method", req.Method,
+					"url", req.URL.String(),
+					"client_request_id", clientRequestID,
+					"headers", RedactHeaders(req.Header))
+			}
+
+			start := time.Now()
+			resp, err := next.RoundTrip(req)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				logger.WarnContext(ctx, "onenote request failed",
+					"method", req.Method,
+					"url", req.URL.String(),
+					"client_request_id", clientRequestID,
+					"duration", elapsed,
+					"error", err)
+				return resp, err
+			}
+
+			logResponse(ctx, logger, req, resp, clientRequestID, elapsed)
+			return resp, err
+		})
+	}
+}
+
+// logResponse logs a response with the ids to correlate it with Graph
+func logResponse(ctx context.Context, logger *slog.Logger, req *http.Request, resp *http.Response, clientRequestID string, elapsed time.Duration) {
+	level := slog.LevelInfo
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+
+	// Graph echoes the client-request-id when asked to
+	if id := resp.Header.Get(ClientRequestIDHeader); id != "" {
+		clientRequestID = id
+	}
+
+	logger.Log(ctx, level, "onenote response",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"status", resp.StatusCode,
+		"duration", elapsed,
+		"client_request_id", clientRequestID,
+		"request_id", resp.Header.Get(RequestIDHeader))
+}
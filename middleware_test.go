@@ -0,0 +1,179 @@
+package onenote_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+)
+
+// uuid matches a random version 4 UUID
+var uuid = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// logRecord is a record written by slog.JSONHandler
+type logRecord struct {
+	Level           string
+	Msg             string
+	Method          string
+	URL             string
+	Status          int
+	Duration        *int64
+	Error           string
+	ClientRequestID string              `json:"client_request_id"`
+	RequestID       string              `json:"request_id"`
+	Headers         map[string][]string `json:"headers"`
+}
+
+func TestLogging(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		err       error
+		echo      bool
+		wantLevel string
+		wantMsg   string
+	}{
+		{"ok", http.StatusOK, nil, true, "INFO", "onenote response"},
+		{"not echoed", http.StatusCreated, nil, false, "INFO", "onenote response"},
+		{"error status", http.StatusNotFound, nil, true, "WARN", "onenote response"},
+		{"transport error", 0, errors.New("connection reset"), false, "WARN", "onenote request failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent http.Header
+			transport := onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				sent = req.Header
+				if tt.err != nil {
+					return nil, tt.err
+				}
+				header := http.Header{}
+				header.Set(onenote.RequestIDHeader, "graph-id")
+				if tt.echo {
+					header.Set(onenote.ClientRequestIDHeader, req.Header.Get(onenote.ClientRequestIDHeader))
+				}
+				return &http.Response{StatusCode: tt.status, Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+			})
+
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			rt := onenote.Chain(transport, onenote.RequestID(), onenote.Logging(logger))
+
+			req, err := http.NewRequest(http.MethodGet, "https://graph.microsoft.com/v1.0/me/onenote/pages", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer secret-token")
+			if _, err := rt.RoundTrip(req); !errors.Is(err, tt.err) {
+				t.Fatalf("RoundTrip() error = %v, want %v", err, tt.err)
+			}
+
+			if strings.Contains(buf.String(), "secret-token") {
+				t.Errorf("log has the token:\n%s", buf.String())
+			}
+			id := sent.Get(onenote.ClientRequestIDHeader)
+			if !uuid.MatchString(id) || sent.Get("return-client-request-id") != "true" {
+				t.Errorf("sent client-request-id %q, return-client-request-id %q", id, sent.Get("return-client-request-id"))
+			}
+			if req.Header.Get(onenote.ClientRequestIDHeader) != "" {
+				t.Errorf("RequestID() changed the request of the caller")
+			}
+
+			var records []logRecord
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var r logRecord
+				if err := dec.Decode(&r); err != nil {
+					t.Fatal(err)
+				}
+				records = append(records, r)
+			}
+			if len(records) != 2 {
+				t.Fatalf("got %d log records, want 2", len(records))
+			}
+
+			request := records[0]
+			if request.Level != "DEBUG" || request.Msg != "onenote request" || request.ClientRequestID != id {
+				t.Errorf("request record = %+v", request)
+			}
+			if got := request.Headers["Authorization"]; len(got) != 1 || got[0] != "REDACTED" {
+				t.Errorf("logged Authorization = %q, want REDACTED", got)
+			}
+
+			response := records[1]
+			if response.Level != tt.wantLevel || response.Msg != tt.wantMsg {
+				t.Errorf("response record is %s %q, want %s %q", response.Level, response.Msg, tt.wantLevel, tt.wantMsg)
+			}
+			if response.ClientRequestID != id || response.Method != http.MethodGet || response.URL != req.URL.String() {
+				t.Errorf("response record = %+v, want client_request_id %q", response, id)
+			}
+			if response.Duration == nil || *response.Duration < 0 {
+				t.Errorf("response record has no duration")
+			}
+			if tt.err != nil {
+				if response.Error != tt.err.Error() {
+					t.Errorf("logged error = %q, want %q", response.Error, tt.err)
+				}
+			} else if response.Status != tt.status || response.RequestID != "graph-id" {
+				t.Errorf("logged status %d, request_id %q, want %d, graph-id", response.Status, response.RequestID, tt.status)
+			}
+		})
+	}
+}
+
+func TestRequestIDKept(t *testing.T) {
+	var sent string
+	transport := onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req.Header.Get(onenote.ClientRequestIDHeader)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "https://graph.microsoft.com/v1.0/me/onenote/pages", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(onenote.ClientRequestIDHeader, "mine")
+	if _, err := onenote.Chain(transport, onenote.RequestID()).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if sent != "mine" {
+		t.Errorf("sent client-request-id %q, want mine", sent)
+	}
+}
+
+func TestTiming(t *testing.T) {
+	transport := onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		time.Sleep(10 * time.Millisecond)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	var calls int
+	timing := onenote.Timing(func(req *http.Request, resp *http.Response, err error, d time.Duration) {
+		calls++
+		if resp == nil || resp.StatusCode != http.StatusOK || err != nil {
+			t.Errorf("timing got %v, %v", resp, err)
+		}
+		if d < 10*time.Millisecond {
+			t.Errorf("duration = %v, want at least 10ms", d)
+		}
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "https://graph.microsoft.com/v1.0/me/onenote/pages", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := onenote.Chain(transport, timing).RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("timing called %d times, want 1", calls)
+	}
+}
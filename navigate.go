@@ -0,0 +1,107 @@
+package onenote
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// navURL returns the URL embedded in an object by Graph, or the URL
+// built from the owner of c and path if it was not returned, for
+// example when excluded by $select
+func (c *Client) navURL(embedded, path string) string {
+	if isAbsolute(embedded) {
+		return embedded
+	}
+	return c.ownerURL() + path
+}
+
+// listSections returns all sections of the list at urlString
+func (c *Client) listSections(ctx context.Context, urlString string) ([]Section, error) {
+	var sections []Section
+	err := c.listAll(ctx, OpListSections, urlString, func(body []byte) (string, error) {
+		var response SectionResponse
+		err := json.Unmarshal(body, &response)
+		sections = append(sections, response.Value...)
+		return response.ODataNextLink, err
+	})
+	return sections, err
+}
+
+// listSectionGroups returns all section groups of the list at urlString
+func (c *Client) listSectionGroups(ctx context.Context, urlString string) ([]SectionGroup, error) {
+	var groups []SectionGroup
+	err := c.listAll(ctx, OpListSectionGroups, urlString, func(body []byte) (string, error) {
+		var response SectionGroupResponse
+		err := json.Unmarshal(body, &response)
+		groups = append(groups, response.Value...)
+		return response.ODataNextLink, err
+	})
+	return groups, err
+}
+
+// Sections returns the sections directly in the notebook, following
+// SectionsUrl so notebooks of other owners work with any Client
+func (nb *Notebook) Sections(ctx context.Context, c *Client) ([]Section, error) {
+	return c.listSections(ctx, c.navURL(nb.SectionsUrl, "/notebooks/"+nb.Id+"/sections"))
+}
+
+// SectionGroups returns the section groups directly in the notebook,
+// following SectionGroupsUrl
+func (nb *Notebook) SectionGroups(ctx context.Context, c *Client) ([]SectionGroup, error) {
+	return c.listSectionGroups(ctx, c.navURL(nb.SectionGroupsUrl, "/notebooks/"+nb.Id+"/sectionGroups"))
+}
+
+// Sections returns the sections directly in the section group,
+// following SectionsUrl
+func (g *SectionGroup) Sections(ctx context.Context, c *Client) ([]Section, error) {
+	return c.listSections(ctx, c.navURL(g.SectionsUrl, "/sectionGroups/"+g.Id+"/sections"))
+}
+
+// SectionGroups returns the section groups nested in the section group,
+// following SectionGroupsUrl
+func (g *SectionGroup) SectionGroups(ctx context.Context, c *Client) ([]SectionGroup, error) {
+	return c.listSectionGroups(ctx, c.navURL(g.SectionGroupsUrl, "/sectionGroups/"+g.Id+"/sectionGroups"))
+}
+
+// Pages returns the pages of the section with their Level and Order,
+// following PagesUrl
+func (s *Section) Pages(ctx context.Context, c *Client) ([]Page, error) {
+	it := &PageIterator{
+		c:     c,
+		url:   c.navURL(s.PagesUrl, "/sections/"+s.Id+"/pages"),
+		query: pageLevelQuery(nil),
+	}
+	return it.All(ctx)
+}
+
+// GetContent returns the HTML content of the page, following ContentUrl.
+// It is not named Content since Page has a Content field.
+func (p *Page) GetContent(ctx context.Context, c *Client) (string, error) {
+	body, err := c.get(ctx, OpGetPageContent, c.navURL(p.ContentUrl, "/pages/"+p.Id+"/content"), nil)
+	return string(body), err
+}
+
+// Parent returns the section of the page. The section is returned
+// without a request if it was expanded with $expand=parentSection,
+// otherwise it is requested using the Self URL of the page.
+func (p *Page) Parent(ctx context.Context, c *Client) (Section, error) {
+	if p.ParentSection.Id != "" {
+		return p.ParentSection, nil
+	}
+
+	var section Section
+	err := c.getJSON(ctx, OpGetPage, c.navURL(p.Self, "/pages/"+p.Id)+"/parentSection", nil, &section)
+	return section, err
+}
+
+// Notebook returns the notebook of the page, without a request if it was
+// expanded with $expand=parentNotebook
+func (p *Page) Notebook(ctx context.Context, c *Client) (Notebook, error) {
+	if p.ParentNotebook.Id != "" {
+		return p.ParentNotebook, nil
+	}
+
+	var notebook Notebook
+	err := c.getJSON(ctx, OpGetPage, c.navURL(p.Self, "/pages/"+p.Id)+"/parentNotebook", nil, &notebook)
+	return notebook, err
+}
@@ -0,0 +1,269 @@
+package onenote_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+// navFixture holds the objects of another owner, listed with their
+// embedded URLs, and a client of "me" recording the paths it requests
+type navFixture struct {
+	srv      *onenotetest.Server
+	client   *onenote.Client
+	paths    []string
+	notebook onenote.Notebook
+	group    onenote.SectionGroup
+	section  onenote.Section
+	page     onenote.Page
+}
+
+// newNavFixture returns the objects of users/alice for a server with
+//
+//	Work/Projects/Launch
+//	Work/Notes/Plan
+func newNavFixture(t *testing.T) *navFixture {
+	t.Helper()
+	srv := onenotetest.NewServer()
+	t.Cleanup(srv.Close)
+
+	work := srv.AddNotebook("Work")
+	srv.AddSection(srv.AddSectionGroup(work.Id, "Projects").Id, "Launch")
+	srv.AddPage(srv.AddSection(work.Id, "Notes").Id, "Plan", "<p>plan</p>")
+
+	ctx := context.Background()
+	alice := srv.Client()
+	alice.Owner = "users/alice"
+	f := &navFixture{srv: srv}
+
+	notebooks, err := alice.ListNotebooks(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := alice.ListSectionGroups(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err := alice.ListSections(ctx, url.Values{"$filter": {"displayName eq 'Notes'"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := alice.ListPages(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notebooks.Value) != 1 || len(groups.Value) != 1 || len(sections.Value) != 1 || len(pages.Value) != 1 {
+		t.Fatalf("got %d notebooks, %d groups, %d sections, %d pages",
+			len(notebooks.Value), len(groups.Value), len(sections.Value), len(pages.Value))
+	}
+	f.notebook, f.group, f.section, f.page = notebooks.Value[0], groups.Value[0], sections.Value[0], pages.Value[0]
+
+	f.client = srv.Client()
+	f.client.Middleware = []onenote.Middleware{func(next http.RoundTripper) http.RoundTripper {
+		return onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			f.paths = append(f.paths, strings.TrimPrefix(req.URL.Path, "/"+onenote.V1))
+			return next.RoundTrip(req)
+		})
+	}}
+	return f
+}
+
+// sectionNames returns the display names of the sections
+func sectionNames(sections []onenote.Section, err error) (string, error) {
+	var names []string
+	for _, s := range sections {
+		names = append(names, s.DisplayName)
+	}
+	return strings.Join(names, ","), err
+}
+
+// groupNames returns the display names of the section groups
+func groupNames(groups []onenote.SectionGroup, err error) (string, error) {
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.DisplayName)
+	}
+	return strings.Join(names, ","), err
+}
+
+func TestNavigate(t *testing.T) {
+	f := newNavFixture(t)
+	nb, g, sec, p := f.notebook, f.group, f.section, f.page
+
+	tests := []struct {
+		name     string
+		nav      func(ctx context.Context, c *onenote.Client) (string, error)
+		want     string
+		wantPath string
+	}{
+		{
+			"notebook sections",
+			func(ctx context.Context, c *onenote.Client) (string, error) {
+				return sectionNames(nb.Sections(ctx, c))
+			},
+			"Notes",
+			"/notebooks/" + nb.Id + "/sections",
+		},
+		{
+			"notebook section groups",
+			func(ctx context.Context, c *onenote.Client) (string, error) {
+				return groupNames(nb.SectionGroups(ctx, c))
+			},
+			"Projects",
+			"/notebooks/" + nb.Id + "/sectionGroups",
+		},
+		{
+			"group sections",
+			func(ctx context.Context, c *onenote.Client) (string, error) {
+				return sectionNames(g.Sections(ctx, c))
+			},
+			"Launch",
+			"/sectionGroups/" + g.Id + "/sections",
+		},
+		{
+			"group section groups",
+			func(ctx context.Context, c *onenote.Client) (string, error) {
+				return groupNames(g.SectionGroups(ctx, c))
+			},
+			"",
+			"/sectionGroups/" + g.Id + "/sectionGroups",
+		},
+		{
+			"section pages",
+			func(ctx context.Context, c *onenote.Client) (string, error) {
+				pages, err := sec.Pages(ctx, c)
+				if len(pages) != 1 {
+					return "", err
+				}
+				return pages[0].Title, err
+			},
+			"Plan",
+			"/sections/" + sec.Id + "/pages",
+		},
+		{
+			"page content",
+			func(ctx context.Context, c *onenote.Client) (string, error) {
+				content, err := p.GetContent(ctx, c)
+				if !strings.Contains(content, "<p>plan</p>") {
+					return content, err
+				}
+				return "plan", err
+			},
+			"plan",
+			"/pages/" + p.Id + "/content",
+		},
+		{
+			"page parent",
+			func(ctx context.Context, c *onenote.Client) (string, error) {
+				s, err := p.Parent(ctx, c)
+				return s.DisplayName, err
+			},
+			"Notes",
+			"/pages/" + p.Id + "/parentSection",
+		},
+		{
+			"page notebook",
+			func(ctx context.Context, c *onenote.Client) (string, error) {
+				nb, err := p.Notebook(ctx, c)
+				return nb.DisplayName, err
+			},
+			"Work",
+			"/pages/" + p.Id + "/parentNotebook",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.paths = nil
+			got, err := tt.nav(context.Background(), f.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			// the embedded URL is of alice, the client of me
+			if want := "/users/alice/onenote" + tt.wantPath; len(f.paths) != 1 || f.paths[0] != want {
+				t.Errorf("requested %q, want %q", f.paths, want)
+			}
+		})
+	}
+}
+
+func TestNavigateWithoutURL(t *testing.T) {
+	f := newNavFixture(t)
+	ctx := context.Background()
+
+	// without the embedded URLs, as with $select, the paths are built
+	// for the owner of the client
+	nb := onenote.Notebook{Id: f.notebook.Id}
+	if sections, err := nb.Sections(ctx, f.client); err != nil || len(sections) != 1 {
+		t.Errorf("Sections() = %d sections, %v", len(sections), err)
+	}
+	p := onenote.Page{Id: f.page.Id}
+	if parent, err := p.Parent(ctx, f.client); err != nil || parent.Id != f.section.Id {
+		t.Errorf("Parent() = %q, %v, want %q", parent.Id, err, f.section.Id)
+	}
+
+	want := []string{
+		"/me/onenote/notebooks/" + nb.Id + "/sections",
+		"/me/onenote/pages/" + p.Id + "/parentSection",
+	}
+	if strings.Join(f.paths, " ") != strings.Join(want, " ") {
+		t.Errorf("requested %q, want %q", f.paths, want)
+	}
+}
+
+func TestNavigateExpanded(t *testing.T) {
+	f := newNavFixture(t)
+	ctx := context.Background()
+
+	p, err := f.client.GetPage(ctx, f.page.Id, url.Values{"$expand": {"parentSection,parentNotebook"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.paths = nil
+	parent, err := p.Parent(ctx, f.client)
+	if err != nil || parent.Id != f.section.Id {
+		t.Errorf("Parent() = %q, %v, want %q", parent.Id, err, f.section.Id)
+	}
+	nb, err := p.Notebook(ctx, f.client)
+	if err != nil || nb.Id != f.notebook.Id {
+		t.Errorf("Notebook() = %q, %v, want %q", nb.Id, err, f.notebook.Id)
+	}
+	if len(f.paths) != 0 {
+		t.Errorf("requested %q, want none", f.paths)
+	}
+}
+
+func TestNavigateParentNotFound(t *testing.T) {
+	f := newNavFixture(t)
+	ctx := context.Background()
+	f.srv.InjectFault(onenotetest.Fault{Path: "/parentSection", Status: http.StatusNotFound})
+	f.srv.InjectFault(onenotetest.Fault{Path: "/parentNotebook", Status: http.StatusNotFound})
+
+	var apiErr *onenote.Error
+	parent, err := f.page.Parent(ctx, f.client)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Parent() error = %v, want 404", err)
+	}
+	if parent.Id != "" {
+		t.Errorf("Parent() = %q, want none", parent.Id)
+	}
+
+	nb, err := f.page.Notebook(ctx, f.client)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Notebook() error = %v, want 404", err)
+	}
+	if nb.Id != "" {
+		t.Errorf("Notebook() = %q, want none", nb.Id)
+	}
+}
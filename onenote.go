@@ -1,11 +1,8 @@
 package onenote
 
 import (
-	"encoding/json"
-	//	"fmt"
-	"io/ioutil"
+	"context"
 	"log"
-	"net/http"
 	"net/url"
 )
 
@@ -54,10 +51,36 @@ type Notebook struct {
 	UserRole             string        `json:"userRole"`
 	IsShared             bool          `json:"isShared"`
 	SectionsUrl          string        `json:"sectionsUrl"`
-	SectionGroupsUrl     string        `json:"sectionsGroupsUrl"`
+	SectionGroupsUrl     string        `json:"sectionGroupsUrl"`
 	Links                NotebookLinks `json:"links"`
 }
 
+type SectionGroup struct {
+	Id                   string      `json:"id"`
+	Self                 string      `json:"self"`
+	CreatedDateTime      string      `json:"createdDateTime"`
+	DisplayName          string      `json:"displayName"`
+	LastModifiedDateTime string      `json:"lastModifiedDateTime"`
+	SectionsUrl          string      `json:"sectionsUrl"`
+	SectionGroupsUrl     string      `json:"sectionGroupsUrl"`
+	CreatedBy            IdentitySet `json:"createdBy"`
+	LastModifiedBy       IdentitySet `json:"lastModifiedBy"`
+
+	// using pointers since a section group may be nested
+	ParentNotebook     *Notebook     `json:"parentNotebook,omitempty"`
+	ParentSectionGroup *SectionGroup `json:"parentSectionGroup,omitempty"`
+}
+
+type SectionGroupResponse struct {
+	OData
+	Value []SectionGroup `json:"value"`
+}
+
+type SectionResponse struct {
+	OData
+	Value []Section `json:"value"`
+}
+
 type PageLinks struct {
 	OneNoteClientUrl ExternalLink `json:"oneNoteClientUrl"`
 	OneNoteWebUrl    ExternalLink `json:"oneNoteWebUrl"`
@@ -99,95 +122,45 @@ type PageResponse struct {
 	ODataContext string `json:"@odata.context"`
 }
 
-
-// get is a helper function to form and execute the HTTP request
-// and return the HTTP response
-func get(token []byte, urlString string, query url.Values) []byte {
-
-	// default HTTP client
-	client := &http.Client{}
-
-	// parse the URL string
-	url, err := url.Parse(urlString)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// add the query parameters to the URL
-	url.RawQuery = query.Encode()
-
-	//fmt.Printf("*****\n%v\n*****\n", url.String())
-
-	// create the HTTP request with proper headers
-	req, err := http.NewRequest("GET", url.String(), nil)
-	req.Header.Add("Host", "graph,microsoft.com")
-	req.Header.Add("Authorization", "Bearer "+string(token))
-
-	// execute HTTP request
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatal(err)
-	}
-	defer resp.Body.Close()
-
-	// read HTTP response
-	body, err := ioutil.ReadAll(resp.Body)
+// ListNotebooks retrives a list of Notebook objects
+// for the signed-in user, see Client.ListNotebooks
+func ListNotebooks(token []byte, query url.Values) NotebookResponse {
+	response, err := NewClient(token).ListNotebooks(context.Background(), query)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// return HTTP response body
-	return body
-}
-
-func unmarshal(body []byte, v interface{}) {
-	err := json.Unmarshal(body, v)
-	if err != nil {
-		log.Fatal("Cannot unmarshal", err)
-	}
-}
-
-const msBaseURL = "https://graph.microsoft.com/v1.0"
-
-// ListNotebooks retrives a list of Notebook objects
-func ListNotebooks(token []byte, query url.Values) NotebookResponse {
-	var body []byte
-
-	body = get(token, msBaseURL+"/me/onenote/notebooks", query)
-
-	var response NotebookResponse
-	unmarshal(body, &response)
-
 	return response
 }
 
 // ListPages retrives a list of Page objects
+// for the signed-in user, see Client.ListPages
 func ListPages(token []byte, query url.Values) PageResponse {
-	var body []byte
-
-	body = get(token, msBaseURL+"/me/onenote/pages", query)
-
-	var response PageResponse
-	unmarshal(body, &response)
+	response, err := NewClient(token).ListPages(context.Background(), query)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	return response
 }
 
+// GetPage retrieves a Page for the signed-in user, see Client.GetPage
 func GetPage(token []byte, id string, query url.Values) Page {
-	var body []byte
-
-	body = get(token, msBaseURL+"/me/onenote/pages/"+id, query)
-
-	var response Page
-	unmarshal(body, &response)
+	response, err := NewClient(token).GetPage(context.Background(), id, query)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	return response
 }
 
+// GetPageContent retrieves the HTML content of a Page
+// for the signed-in user, see Client.GetPageContent
 func GetPageContent(token []byte, id string, query url.Values) string {
-	var body []byte
-
-	body = get(token, msBaseURL+"/me/onenote/pages/"+id+"/content", query)
+	content, err := NewClient(token).GetPageContent(context.Background(), id, query)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	return string(body)
+	return content
 }
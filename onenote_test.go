@@ -0,0 +1,79 @@
+package onenote
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// notebooksPayload is a response of GET /me/onenote/notebooks as returned
+// by Microsoft Graph, shortened
+const notebooksPayload = `{
+  "@odata.context": "https://graph.microsoft.com/v1.0/$metadata#users('user%40contoso.com')/onenote/notebooks",
+  "value": [
+    {
+      "id": "1-8a6ea3a6-7ab5-4bb5-9ef4-0a3e0fc2e53b",
+      "self": "https://graph.microsoft.com/v1.0/users/user@contoso.com/onenote/notebooks/1-8a6ea3a6-7ab5-4bb5-9ef4-0a3e0fc2e53b",
+      "createdDateTime": "2023-01-05T17:01:22Z",
+      "displayName": "Work",
+      "lastModifiedDateTime": "2023-02-01T09:12:44Z",
+      "isDefault": false,
+      "userRole": "Owner",
+      "isShared": false,
+      "sectionsUrl": "https://graph.microsoft.com/v1.0/users/user@contoso.com/onenote/notebooks/1-8a6ea3a6-7ab5-4bb5-9ef4-0a3e0fc2e53b/sections",
+      "sectionGroupsUrl": "https://graph.microsoft.com/v1.0/users/user@contoso.com/onenote/notebooks/1-8a6ea3a6-7ab5-4bb5-9ef4-0a3e0fc2e53b/sectionGroups",
+      "createdBy": {"user": {"id": "b5c4d2a1", "displayName": "Alex Wilber"}},
+      "links": {
+        "oneNoteClientUrl": {"href": "onenote:https://contoso-my.sharepoint.com/personal/user/Documents/Work"},
+        "oneNoteWebUrl": {"href": "https://contoso-my.sharepoint.com/personal/user/Documents/Work"}
+      }
+    }
+  ]
+}`
+
+// sectionGroupsPayload is a response of GET /me/onenote/sectionGroups
+const sectionGroupsPayload = `{
+  "value": [
+    {
+      "id": "1-2f7e9b64-0d1b-4c1e-9d2a-5f0c1a3b7d21",
+      "displayName": "Projects",
+      "sectionsUrl": "https://graph.microsoft.com/v1.0/me/onenote/sectionGroups/1-2f7e9b64-0d1b-4c1e-9d2a-5f0c1a3b7d21/sections",
+      "sectionGroupsUrl": "https://graph.microsoft.com/v1.0/me/onenote/sectionGroups/1-2f7e9b64-0d1b-4c1e-9d2a-5f0c1a3b7d21/sectionGroups",
+      "parentNotebook": {"id": "1-8a6ea3a6-7ab5-4bb5-9ef4-0a3e0fc2e53b", "displayName": "Work"}
+    }
+  ]
+}`
+
+func TestDecodeGraphPayload(t *testing.T) {
+	var notebooks NotebookResponse
+	if err := json.Unmarshal([]byte(notebooksPayload), &notebooks); err != nil {
+		t.Fatal(err)
+	}
+	var groups SectionGroupResponse
+	if err := json.Unmarshal([]byte(sectionGroupsPayload), &groups); err != nil {
+		t.Fatal(err)
+	}
+	if len(notebooks.Value) != 1 || len(groups.Value) != 1 {
+		t.Fatalf("got %d notebooks and %d section groups, want 1 and 1", len(notebooks.Value), len(groups.Value))
+	}
+	nb, g := notebooks.Value[0], groups.Value[0]
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"notebook sectionsUrl", nb.SectionsUrl, "https://graph.microsoft.com/v1.0/users/user@contoso.com/onenote/notebooks/1-8a6ea3a6-7ab5-4bb5-9ef4-0a3e0fc2e53b/sections"},
+		{"notebook sectionGroupsUrl", nb.SectionGroupsUrl, "https://graph.microsoft.com/v1.0/users/user@contoso.com/onenote/notebooks/1-8a6ea3a6-7ab5-4bb5-9ef4-0a3e0fc2e53b/sectionGroups"},
+		{"notebook user", nb.CreatedBy.User.DisplayName, "Alex Wilber"},
+		{"notebook web link", nb.Links.OneNoteWebUrl.Href, "https://contoso-my.sharepoint.com/personal/user/Documents/Work"},
+		{"section group sectionsUrl", g.SectionsUrl, "https://graph.microsoft.com/v1.0/me/onenote/sectionGroups/1-2f7e9b64-0d1b-4c1e-9d2a-5f0c1a3b7d21/sections"},
+		{"section group sectionGroupsUrl", g.SectionGroupsUrl, "https://graph.microsoft.com/v1.0/me/onenote/sectionGroups/1-2f7e9b64-0d1b-4c1e-9d2a-5f0c1a3b7d21/sectionGroups"},
+		{"section group parent", g.ParentNotebook.DisplayName, "Work"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,531 @@
+package onenotetest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// RecordEnv selects record mode in OpenCassette when set to a non-empty
+// value, for example ONENOTE_RECORD=1 go test ./...
+const RecordEnv = "ONENOTE_RECORD"
+
+// CassetteDir is where OpenCassette keeps recordings, relative to the
+// package directory of the test
+var CassetteDir = filepath.Join("testdata", "cassettes")
+
+// ErrNoInteraction is returned on replay when no unused recorded
+// interaction matches a request
+var ErrNoInteraction = errors.New("onenotetest: no recorded interaction matches request")
+
+// Cassette is a list of recorded HTTP exchanges
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a scrubbed HTTP request
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitempty"`
+}
+
+// RecordedResponse is a scrubbed HTTP response
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitempty"`
+}
+
+// Body is stored as text when it is valid UTF-8 and as base64 otherwise
+type Body []byte
+
+// MarshalJSON stores text bodies as strings for readable diffs
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON reads a string or base64 body
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*b = Body(s)
+		return nil
+	}
+
+	var enc struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(enc.Base64)
+	*b = decoded
+	return err
+}
+
+// Recorder is an http.RoundTripper that records exchanges with the real
+// API to a cassette file, or replays them from the file
+type Recorder struct {
+	// Path of the cassette file
+	Path string
+
+	// Recording is true in record mode and false in replay mode
+	Recording bool
+
+	// Transport sends requests in record mode,
+	// http.DefaultTransport if nil
+	Transport http.RoundTripper
+
+	// Scrub is called on each interaction before it is saved, after the
+	// built-in scrubbing of tokens and user identities
+	Scrub func(*Interaction)
+
+	// Match reports whether a recorded request matches a request,
+	// by default the method, URL with sorted query and body must match.
+	// The request and its body are scrubbed like the recorded requests.
+	Match func(req *http.Request, body []byte, recorded RecordedRequest) bool
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// OpenCassette returns a Recorder for the named cassette in CassetteDir,
+// recording with transport if $ONENOTE_RECORD is set and replaying
+// otherwise. Call Close when done to save a recording.
+func OpenCassette(name string, transport http.RoundTripper) (*Recorder, error) {
+	path := filepath.Join(CassetteDir, name+".json")
+	if os.Getenv(RecordEnv) != "" {
+		return NewRecorder(path, transport), nil
+	}
+	return NewReplayer(path)
+}
+
+// NewRecorder returns a Recorder that sends requests with transport
+// and saves the exchanges to path on Close
+func NewRecorder(path string, transport http.RoundTripper) *Recorder {
+	return &Recorder{
+		Path:      path,
+		Recording: true,
+		Transport: transport,
+	}
+}
+
+// NewReplayer returns a Recorder that answers requests from the
+// cassette at path without using the network
+func NewReplayer(path string) (*Recorder, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{Path: path}
+	if err := json.Unmarshal(b, &r.cassette); err != nil {
+		return nil, fmt.Errorf("onenotetest: cannot parse %s: %w", path, err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+
+	return r, nil
+}
+
+// HTTPClient returns an http.Client using the Recorder,
+// for use as onenote.Client.HTTPClient
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays one exchange
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if r.Recording {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+// record sends the request and keeps the scrubbed exchange
+func (r *Recorder) record(req *http.Request, body []byte) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := &Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    req.URL.String(),
+			Header: req.Header.Clone(),
+			Body:   body,
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       respBody,
+		},
+	}
+
+	scrub(in)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.Scrub != nil {
+		r.Scrub(in)
+	}
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+
+	return resp, nil
+}
+
+// replay returns the response of the first unused matching interaction
+func (r *Recorder) replay(req *http.Request, body []byte) (*http.Response, error) {
+	match := r.Match
+	if match == nil {
+		match = DefaultMatch
+	}
+
+	// the request is scrubbed like the recorded requests, so requests
+	// with the real identities, such as users/{id}, match
+	scrubbed := RecordedRequest{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: body}
+	scrubRequest(&scrubbed)
+	u, err := url.Parse(scrubbed.URL)
+	if err != nil {
+		return nil, err
+	}
+	matchReq := req.Clone(req.Context())
+	matchReq.URL = u
+	matchReq.Header = scrubbed.Header
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || !match(matchReq, scrubbed.Body, in.Request) {
+			continue
+		}
+		r.used[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        in.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, req.Method, req.URL)
+}
+
+// Close saves the cassette in record mode
+func (r *Recorder) Close() error {
+	if !r.Recording {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := json.MarshalIndent(&r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, b, 0644)
+}
+
+// DefaultMatch matches the method, the URL with its query parameters in
+// any order and, for requests with a body, the body
+func DefaultMatch(req *http.Request, body []byte, recorded RecordedRequest) bool {
+	if req.Method != recorded.Method {
+		return false
+	}
+
+	u, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	if canonicalURL(req.URL) != canonicalURL(u) {
+		return false
+	}
+
+	return bytes.Equal(body, recorded.Body)
+}
+
+// canonicalURL returns the URL with sorted query parameters
+func canonicalURL(u *url.URL) string {
+	q := u.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(u.Scheme + "://" + u.Host + u.Path)
+	for i, k := range keys {
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		b.WriteString(k + "=" + strings.Join(vals, ","))
+	}
+	return b.String()
+}
+
+// scrubbed headers that carry credentials or tie a recording to a session
+var scrubbedHeaders = []string{
+	"Authorization", "Cookie", "Set-Cookie", "X-Ms-Ags-Diagnostic",
+}
+
+var (
+	// emailPattern matches email addresses and user principal names,
+	// also with the @ escaped in URLs
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+(?:@|%40)[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// usersPattern matches the user id or name in a users/{id} or
+	// users('{id}') path or the personal site of a user, personal/{name}
+	usersPattern = regexp.MustCompile(`(/users/|users\('|/personal/)([^/?#"'()\s]+)`)
+
+	// placeholderPattern matches the placeholders of identities
+	placeholderPattern = regexp.MustCompile(`^[a-z]+-[0-9a-f]{8}$`)
+)
+
+// identityKeys are the identity set members of Graph objects, such as
+// createdBy.user
+var identityKeys = map[string]bool{"user": true, "application": true, "device": true}
+
+// placeholder returns a stable placeholder for an identity value, the
+// same value always becomes the same placeholder so scrubbed requests
+// match when recorded and replayed
+func placeholder(kind, value string) string {
+	if strings.HasSuffix(value, "@example.com") || placeholderPattern.MatchString(value) {
+		return value
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	hash := hex.EncodeToString(sum[:4])
+	if kind == "email" {
+		return "user-" + hash + "@example.com"
+	}
+	return kind + "-" + hash
+}
+
+// scrubEmails replaces the email addresses in text
+func scrubEmails(text string) string {
+	return emailPattern.ReplaceAllStringFunc(text, func(email string) string {
+		if strings.Contains(email, "%40") {
+			return strings.Replace(placeholder("email", strings.Replace(email, "%40", "@", 1)), "@", "%40", 1)
+		}
+		return placeholder("email", email)
+	})
+}
+
+// scrubUser returns the placeholder of the id or name of a user
+func scrubUser(id string) string {
+	if dec, err := url.PathUnescape(id); err == nil {
+		id = dec
+	}
+	if emailPattern.MatchString(id) {
+		return placeholder("email", id)
+	}
+	return placeholder("user", id)
+}
+
+// scrubText replaces email addresses and the users of users/{id},
+// users('{id}') and personal/{name} paths
+func scrubText(text string) string {
+	text = usersPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := usersPattern.FindStringSubmatch(m)
+		return sub[1] + scrubUser(sub[2])
+	})
+	return scrubEmails(text)
+}
+
+// scrubURL replaces the identities in the path segments, query values
+// and fragment of a URL, other text is left as is
+func scrubURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	segs := strings.Split(u.EscapedPath(), "/")
+	for i, seg := range segs {
+		dec, err := url.PathUnescape(seg)
+		if err != nil {
+			continue
+		}
+
+		scrubbed := scrubText(dec)
+		if i > 0 && (segs[i-1] == "users" || segs[i-1] == "personal") {
+			scrubbed = scrubUser(dec)
+		}
+
+		// keep the escaping of segments that do not change
+		switch {
+		case scrubbed == dec:
+		case seg == dec:
+			segs[i] = scrubbed
+		default:
+			segs[i] = url.PathEscape(scrubbed)
+		}
+	}
+	u.RawPath = strings.Join(segs, "/")
+	u.Path, _ = url.PathUnescape(u.RawPath)
+
+	// the query and fragment are only encoded again if they change
+	q, changed := u.Query(), false
+	for _, vals := range q {
+		for i, v := range vals {
+			if scrubbed := scrubText(v); scrubbed != v {
+				vals[i], changed = scrubbed, true
+			}
+		}
+	}
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+	if scrubbed := scrubText(u.Fragment); scrubbed != u.Fragment {
+		u.Fragment, u.RawFragment = scrubbed, ""
+	}
+
+	return u.String()
+}
+
+// scrubBody replaces the identities in a body. In JSON bodies only
+// string values change: identity sets such as createdBy.user, URLs and
+// email addresses. Other text bodies, such as page HTML, only have email
+// addresses and users/{id} paths replaced, and binary bodies are kept.
+func scrubBody(body []byte) []byte {
+	if len(body) == 0 || !utf8.Valid(body) {
+		return body
+	}
+
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var v interface{}
+	if d.Decode(&v) != nil || d.More() {
+		return []byte(scrubText(string(body)))
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(scrubValue(v)) != nil {
+		return body
+	}
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+}
+
+// scrubValue returns the JSON value with its identities replaced
+func scrubValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			if identityKeys[k] {
+				if identity, ok := child.(map[string]interface{}); ok {
+					for _, field := range []string{"id", "displayName"} {
+						if s, ok := identity[field].(string); ok && s != "" {
+							identity[field] = placeholder(k, s)
+						}
+					}
+				}
+			}
+			t[k] = scrubValue(child)
+		}
+		return t
+	case []interface{}:
+		for i, child := range t {
+			t[i] = scrubValue(child)
+		}
+		return t
+	case string:
+		if strings.HasPrefix(t, "https://") || strings.HasPrefix(t, "http://") {
+			return scrubURL(t)
+		}
+		return scrubText(t)
+	}
+	return v
+}
+
+// scrubRequest removes credentials and identities from a request, it is
+// used for recorded requests and for requests matched on replay
+func scrubRequest(r *RecordedRequest) {
+	for _, h := range scrubbedHeaders {
+		r.Header.Del(h)
+	}
+	r.Header.Del("Content-Length")
+
+	r.URL = scrubURL(r.URL)
+	r.Body = scrubBody(r.Body)
+}
+
+// scrub removes credentials and user identities from an interaction
+func scrub(in *Interaction) {
+	scrubRequest(&in.Request)
+
+	for _, h := range scrubbedHeaders {
+		in.Response.Header.Del(h)
+	}
+	// scrubbing changes the length of the body
+	in.Response.Header.Del("Content-Length")
+	in.Response.Body = scrubBody(in.Response.Body)
+}
@@ -0,0 +1,261 @@
+package onenotetest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+// the identities of the account the cassettes were recorded with, as
+// used by the tests; they must not appear in the cassettes
+const (
+	userEmail = "AlexW@contoso.com"
+	userID    = "6e1c9d1f-3b2a-4c5d-8e7f-9a0b1c2d3e4f"
+	sectionID = "1-8d2f1c3a-4b5e-6f70-8192-a3b4c5d6e7f8"
+	pageID    = "1-5a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d!" + sectionID
+)
+
+// cassetteClient returns a Client replaying the named cassette, or
+// recording it with the token in $ONENOTE_TOKEN if $ONENOTE_RECORD is set
+func cassetteClient(t *testing.T, name string) *onenote.Client {
+	t.Helper()
+
+	rec, err := onenotetest.OpenCassette(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	client := onenote.NewClient([]byte(os.Getenv("ONENOTE_TOKEN")))
+	client.HTTPClient = rec.HTTPClient()
+	return client
+}
+
+func TestCassetteReplay(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		cassette string
+		run      func(t *testing.T, c *onenote.Client)
+	}{
+		{"notebooks-user", func(t *testing.T, c *onenote.Client) {
+			c.Owner = "users/" + userEmail
+			response, err := c.ListNotebooks(ctx, url.Values{"$expand": {"sections"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(response.Value) != 1 {
+				t.Fatalf("got %d notebooks, want 1", len(response.Value))
+			}
+			nb := response.Value[0]
+			if nb.DisplayName != "A" || nb.SectionGroupsUrl == "" {
+				t.Errorf("notebook = %q with sectionGroupsUrl %q", nb.DisplayName, nb.SectionGroupsUrl)
+			}
+			if name := nb.CreatedBy.User.DisplayName; name == "" || name == "Alex Wilber" {
+				t.Errorf("CreatedBy.User.DisplayName = %q, want a placeholder", name)
+			}
+		}},
+		{"pages-paging", func(t *testing.T, c *onenote.Client) {
+			c.Owner = "users/" + userID
+			pages, err := c.Pages(url.Values{"$top": {"2"}}).All(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, p := range pages {
+				titles = append(titles, p.Title)
+			}
+			if got := strings.Join(titles, ","); got != "Standup,Actions,Retro" {
+				t.Errorf("titles = %s, want Standup,Actions,Retro", got)
+			}
+		}},
+		{"page-content", func(t *testing.T, c *onenote.Client) {
+			html, err := c.GetPageContent(ctx, pageID, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(html, "A &lt; B") || !strings.Contains(html, `data-tag="to-do"`) {
+				t.Errorf("content %q lost its text", html)
+			}
+
+			src := regexp.MustCompile(`src="([^"]+)"`).FindStringSubmatch(html)
+			if src == nil {
+				t.Fatal("no image in content")
+			}
+			data, err := c.GetResource(ctx, src[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+				t.Errorf("resource = %q, want a PNG", data)
+			}
+		}},
+		{"create-update", func(t *testing.T, c *onenote.Client) {
+			c.Owner = "users/" + userEmail
+			page, err := c.CreatePage(ctx, sectionID,
+				`<html><head><title>Notes for Alex</title></head><body><p data-id="first">Ask `+userEmail+`</p></body></html>`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Title != "Notes for Alex" {
+				t.Errorf("Title = %q, want %q", page.Title, "Notes for Alex")
+			}
+
+			err = c.UpdatePage(ctx, page.Id, []onenote.PatchCommand{
+				{Target: "#first", Action: "insert", Position: "after", Content: "<p>A</p>"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = c.UpdatePage(ctx, page.Id, []onenote.PatchCommand{
+				{Target: "#missing", Action: "append", Content: "<p>x</p>"},
+			})
+			var apiErr *onenote.Error
+			if !errors.As(err, &apiErr) || apiErr.Code != "19999" {
+				t.Errorf("UpdatePage() error = %v, want code 19999", err)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cassette, func(t *testing.T) {
+			tt.run(t, cassetteClient(t, tt.cassette))
+		})
+	}
+}
+
+func TestCassettesScrubbed(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(onenotetest.CassetteDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no cassettes")
+	}
+
+	email := regexp.MustCompile(`[A-Za-z0-9._%+\-]+(@|%40)[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{userID, "Alex Wilber", "alexw_contoso_com", "Authorization", "Bearer"} {
+			if bytes.Contains(b, []byte(secret)) {
+				t.Errorf("%s contains %q", file, secret)
+			}
+		}
+		for _, m := range email.FindAll(b, -1) {
+			if !bytes.HasSuffix(m, []byte("example.com")) {
+				t.Errorf("%s contains email %s", file, m)
+			}
+		}
+	}
+}
+
+func TestScrub(t *testing.T) {
+	const (
+		response = `{"value":[{"displayName":"A","title":"Notes for A",` +
+			`"self":"https://graph.microsoft.com/v1.0/users/` + userID + `/onenote/pages/1",` +
+			`"createdBy":{"user":{"id":"` + userID + `","displayName":"A"}}}]}`
+		content = `<p>A &amp; B wrote to ` + userEmail + `</p>`
+	)
+
+	live := onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		body := response
+		if strings.HasSuffix(req.URL.Path, "/content") {
+			body = content
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Set-Cookie": {"session=1"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+
+	path := filepath.Join(t.TempDir(), "scrub.json")
+	requests := []string{
+		"https://graph.microsoft.com/v1.0/users/" + userID + "/onenote/pages",
+		"https://graph.microsoft.com/v1.0/users/" + userEmail + "/onenote/pages/1/content",
+	}
+
+	do := func(client *http.Client, urlString string) (string, error) {
+		req, err := http.NewRequest(http.MethodGet, urlString, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return string(b), err
+	}
+
+	rec := onenotetest.NewRecorder(path, live)
+	for _, u := range requests {
+		if _, err := do(rec.HTTPClient(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(b)
+
+	tests := []struct {
+		name string
+		text string
+		want bool
+	}{
+		{"user id", userID, false},
+		{"email", userEmail, false},
+		{"token", "secret", false},
+		{"cookie", "session=1", false},
+		{"short display name", `\"displayName\":\"A\"`, true},
+		{"title", "Notes for A", true},
+		{"content text", "A \\u0026amp; B wrote to user-", true},
+	}
+	for _, tt := range tests {
+		if got := strings.Contains(saved, tt.text); got != tt.want {
+			t.Errorf("%s: cassette contains %q = %v, want %v", tt.name, tt.text, got, tt.want)
+		}
+	}
+
+	// requests with the real identities match the scrubbed recording
+	replay, err := onenotetest.NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range requests {
+		body, err := do(replay.HTTPClient(), u)
+		if err != nil {
+			t.Fatalf("replay %s: %v", u, err)
+		}
+		if strings.Contains(body, userID) || strings.Contains(body, userEmail) {
+			t.Errorf("replay %s = %s, not scrubbed", u, body)
+		}
+	}
+}
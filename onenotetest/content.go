@@ -0,0 +1,221 @@
+package onenotetest
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// inputDoc is the page created from input HTML
+type inputDoc struct {
+	title   string
+	created string
+	body    string
+}
+
+// parseInput reads the input HTML of a new page, replacing name:
+// references to multipart parts with the URLs of the stored resources
+func parseInput(presentation []byte, partURLs map[string]string) (*inputDoc, error) {
+	root, err := html.Parse(bytes.NewReader(presentation))
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &inputDoc{}
+	var body *html.Node
+	var missing []string
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				doc.title = strings.TrimSpace(textOf(n))
+			case atom.Meta:
+				if attr(n, "name") == "created" {
+					doc.created = attr(n, "content")
+				}
+			case atom.Body:
+				body = n
+			}
+
+			for i, a := range n.Attr {
+				if !strings.HasPrefix(a.Val, "name:") {
+					continue
+				}
+				u, ok := partURLs[strings.TrimPrefix(a.Val, "name:")]
+				if !ok {
+					missing = append(missing, a.Val)
+					continue
+				}
+				n.Attr[i].Val = u
+				if n.DataAtom == atom.Img && a.Key == "src" {
+					n.Attr = append(n.Attr, html.Attribute{Key: "data-fullres-src", Val: u})
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing parts %s", strings.Join(missing, ", "))
+	}
+
+	if body != nil {
+		var b strings.Builder
+		for c := body.FirstChild; c != nil; c = c.NextSibling {
+			if err := html.Render(&b, c); err != nil {
+				return nil, err
+			}
+		}
+		doc.body = strings.TrimSpace(b.String())
+	}
+
+	return doc, nil
+}
+
+// patchCommand is one change to the content of a page
+type patchCommand struct {
+	Target   string `json:"target"`
+	Action   string `json:"action"`
+	Position string `json:"position"`
+	Content  string `json:"content"`
+}
+
+// applyPatch applies the commands to the page HTML and returns the new
+// HTML and the new title, or an empty title if it did not change
+func applyPatch(content string, commands []patchCommand) (string, string, error) {
+	root, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", "", err
+	}
+
+	body := find(root, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+	if body == nil {
+		return "", "", fmt.Errorf("page has no body")
+	}
+
+	var newTitle string
+
+	for _, cmd := range commands {
+		if cmd.Target == "title" {
+			if cmd.Action != "replace" {
+				return "", "", fmt.Errorf("unsupported action %q for title", cmd.Action)
+			}
+			title := find(root, func(n *html.Node) bool { return n.DataAtom == atom.Title })
+			if title == nil {
+				return "", "", fmt.Errorf("page has no title")
+			}
+			for title.FirstChild != nil {
+				title.RemoveChild(title.FirstChild)
+			}
+			title.AppendChild(&html.Node{Type: html.TextNode, Data: cmd.Content})
+			newTitle = cmd.Content
+			continue
+		}
+
+		target := body
+		if cmd.Target != "body" {
+			target = findTarget(body, cmd.Target)
+			if target == nil {
+				return "", "", fmt.Errorf("target %q not found", cmd.Target)
+			}
+		}
+
+		context := target
+		if cmd.Action == "insert" || cmd.Action == "replace" {
+			context = target.Parent
+		}
+		nodes, err := html.ParseFragment(strings.NewReader(cmd.Content), context)
+		if err != nil {
+			return "", "", err
+		}
+
+		switch cmd.Action {
+		case "append":
+			for _, n := range nodes {
+				target.AppendChild(n)
+			}
+		case "prepend":
+			first := target.FirstChild
+			for _, n := range nodes {
+				target.InsertBefore(n, first)
+			}
+		case "insert":
+			next := target.NextSibling
+			if cmd.Position == "before" {
+				next = target
+			}
+			for _, n := range nodes {
+				target.Parent.InsertBefore(n, next)
+			}
+		case "replace":
+			if target == body {
+				return "", "", fmt.Errorf("cannot replace body")
+			}
+			for _, n := range nodes {
+				target.Parent.InsertBefore(n, target)
+			}
+			target.Parent.RemoveChild(target)
+		default:
+			return "", "", fmt.Errorf("unsupported action %q", cmd.Action)
+		}
+	}
+
+	var b strings.Builder
+	if err := html.Render(&b, root); err != nil {
+		return "", "", err
+	}
+
+	return b.String(), newTitle, nil
+}
+
+// findTarget returns the element with the data-id "#id" or generated id
+func findTarget(root *html.Node, target string) *html.Node {
+	if strings.HasPrefix(target, "#") {
+		dataID := target[1:]
+		return find(root, func(n *html.Node) bool { return attr(n, "data-id") == dataID })
+	}
+	return find(root, func(n *html.Node) bool { return attr(n, "id") == target })
+}
+
+// find returns the first element below root for which match is true
+func find(root *html.Node, match func(*html.Node) bool) *html.Node {
+	if root.Type == html.ElementNode && match(root) {
+		return root
+	}
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if n := find(c, match); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+// attr returns the value of the named attribute of n
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textOf returns the text inside n
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+	}
+	return b.String()
+}
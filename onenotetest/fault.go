@@ -0,0 +1,99 @@
+package onenotetest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault makes the Server fail matching requests, for example to test
+// the handling of throttling or outages
+type Fault struct {
+	// Method to match, any method if empty
+	Method string
+
+	// Path is a substring of the URL path to match, any path if empty
+	Path string
+
+	// Status is the HTTP status to return, such as 429 or 500
+	Status int
+
+	// RetryAfter sets the Retry-After header if not zero
+	RetryAfter time.Duration
+
+	// Times is the number of requests to fail, all if zero
+	Times int
+
+	// Code and Message of the error body, defaults based on Status
+	Code    string
+	Message string
+
+	// Block delays the matched requests until it is closed or the
+	// request is canceled, other requests are served meanwhile. The
+	// request is then served as usual if Status is zero.
+	Block <-chan struct{}
+}
+
+// InjectFault adds a fault, faults are checked in the order added
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// matchFault returns the first fault matching the request and counts its
+// use, the caller must hold s.mu
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if f.Path != "" && !strings.Contains(r.URL.Path, f.Path) {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// write writes the error response of the fault
+func (f *Fault) write(w http.ResponseWriter) {
+	code, message := f.Code, f.Message
+	if code == "" {
+		switch f.Status {
+		case http.StatusTooManyRequests:
+			code = "20166"
+		case http.StatusServiceUnavailable:
+			code = "serviceNotAvailable"
+		default:
+			code = "generalException"
+		}
+	}
+	if message == "" {
+		message = http.StatusText(f.Status)
+	}
+
+	if f.RetryAfter > 0 {
+		seconds := int((f.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+
+	writeError(w, f.Status, code, message)
+}
@@ -0,0 +1,438 @@
+package onenotetest
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// serveHTTP routes a request to the fake API
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	f := s.matchFault(r)
+	if f != nil && f.Block != nil {
+		// serve other requests while blocked
+		s.mu.Unlock()
+		select {
+		case <-f.Block:
+		case <-r.Context().Done():
+		}
+		s.mu.Lock()
+	}
+	defer s.mu.Unlock()
+
+	if f != nil && f.Status != 0 {
+		f.write(w)
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "InvalidAuthenticationToken",
+			"Access token is empty.")
+		return
+	}
+
+	// path is /{version}/{owner...}/onenote/{segments...}
+	i := strings.Index(r.URL.Path, "/onenote/")
+	if i < 0 {
+		writeError(w, http.StatusBadRequest, "BadRequest", "Invalid request.")
+		return
+	}
+	base := "http://" + r.Host + r.URL.Path[:i+len("/onenote")]
+	segs := strings.Split(strings.Trim(r.URL.Path[i+len("/onenote/"):], "/"), "/")
+
+	h := &handler{s: s, w: w, r: r, base: base}
+	h.route(segs)
+}
+
+// handler serves one request, s.mu is held
+type handler struct {
+	s    *Server
+	w    http.ResponseWriter
+	r    *http.Request
+	base string
+}
+
+func (h *handler) route(segs []string) {
+	method := h.r.Method
+	get := method == http.MethodGet
+
+	switch {
+	case len(segs) == 1 && segs[0] == "notebooks" && get:
+		h.list(h.s.notebookItems(h.base, nil))
+	case len(segs) == 2 && segs[0] == "notebooks" && get:
+		nb := h.s.findNotebook(segs[1])
+		if nb == nil {
+			h.notFound()
+			return
+		}
+		h.item(h.s.notebookItem(h.base, nb))
+	case len(segs) == 3 && segs[0] == "notebooks" && get:
+		if h.s.findNotebook(segs[1]) == nil {
+			h.notFound()
+			return
+		}
+		h.children(segs[1], "", segs[2])
+
+	case len(segs) == 1 && segs[0] == "sectionGroups" && get:
+		h.list(h.s.sectionGroupItems(h.base, func(*sectionGroup) bool { return true }))
+	case len(segs) == 2 && segs[0] == "sectionGroups" && get:
+		g := h.s.findSectionGroup(segs[1])
+		if g == nil {
+			h.notFound()
+			return
+		}
+		h.item(h.s.sectionGroupItem(h.base, g))
+	case len(segs) == 3 && segs[0] == "sectionGroups" && get:
+		g := h.s.findSectionGroup(segs[1])
+		if g == nil {
+			h.notFound()
+			return
+		}
+		h.children(g.notebookID, g.Id, segs[2])
+
+	case len(segs) == 1 && segs[0] == "sections" && get:
+		h.list(h.s.sectionItems(h.base, func(*section) bool { return true }))
+	case len(segs) == 2 && segs[0] == "sections" && get:
+		sec := h.s.findSection(segs[1])
+		if sec == nil {
+			h.notFound()
+			return
+		}
+		h.item(h.s.sectionItem(h.base, sec))
+	case len(segs) == 3 && segs[0] == "sections" && segs[2] == "pages":
+		if h.s.findSection(segs[1]) == nil {
+			h.notFound()
+			return
+		}
+		switch method {
+		case http.MethodGet:
+			h.list(h.pageLevels(h.s.pageItems(h.base, func(p *page) bool { return p.sectionID == segs[1] }), true))
+		case http.MethodPost:
+			h.createPage(segs[1])
+		default:
+			h.methodNotAllowed()
+		}
+
+	case len(segs) == 1 && segs[0] == "pages":
+		switch method {
+		case http.MethodGet:
+			h.list(h.pageLevels(h.s.pageItems(h.base, func(*page) bool { return true }), false))
+		case http.MethodPost:
+			h.createPage(h.s.defaultSectionID())
+		default:
+			h.methodNotAllowed()
+		}
+	case len(segs) == 2 && segs[0] == "pages":
+		p := h.s.findPage(segs[1])
+		if p == nil {
+			h.notFound()
+			return
+		}
+		switch method {
+		case http.MethodGet:
+			h.item(h.pageLevels([]item{h.s.pageItem(h.base, p)}, true)[0])
+		case http.MethodDelete:
+			h.s.deletePage(p.Id)
+			h.w.WriteHeader(http.StatusNoContent)
+		default:
+			h.methodNotAllowed()
+		}
+	case len(segs) == 3 && segs[0] == "pages" && segs[2] == "content":
+		p := h.s.findPage(segs[1])
+		if p == nil {
+			h.notFound()
+			return
+		}
+		switch method {
+		case http.MethodGet:
+			h.w.Header().Set("Content-Type", "text/html")
+			io.WriteString(h.w, p.content)
+		case http.MethodPatch:
+			h.patchPage(p)
+		default:
+			h.methodNotAllowed()
+		}
+	case len(segs) == 3 && segs[0] == "pages" && get:
+		// parentNotebook and parentSection navigation properties
+		p := h.s.findPage(segs[1])
+		if p == nil {
+			h.notFound()
+			return
+		}
+		full := h.s.pageItem(h.base, p)
+		parent, ok := full[segs[2]].(item)
+		if !ok || (segs[2] != "parentNotebook" && segs[2] != "parentSection") {
+			h.notFound()
+			return
+		}
+		h.item(parent)
+
+	case len(segs) == 3 && segs[0] == "resources" && get &&
+		(segs[2] == "$value" || segs[2] == "content"):
+		res, ok := h.s.resources[segs[1]]
+		if !ok {
+			h.notFound()
+			return
+		}
+		h.w.Header().Set("Content-Type", res.contentType)
+		h.w.Write(res.data)
+
+	default:
+		h.notFound()
+	}
+}
+
+// children lists the sections or section groups directly inside the
+// notebook or section group
+func (h *handler) children(notebookID, groupID, kind string) {
+	switch kind {
+	case "sections":
+		h.list(h.s.sectionItems(h.base, func(sec *section) bool {
+			return sec.notebookID == notebookID && sec.groupID == groupID
+		}))
+	case "sectionGroups":
+		h.list(h.s.sectionGroupItems(h.base, func(g *sectionGroup) bool {
+			return g.notebookID == notebookID && g.parentID == groupID
+		}))
+	default:
+		h.notFound()
+	}
+}
+
+// list writes a collection response after applying the query options
+func (h *handler) list(items []item) {
+	q := h.r.URL.Query()
+
+	items, err := filterItems(items, q.Get("$filter"))
+	if err != nil {
+		writeError(h.w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+	orderItems(items, q.Get("$orderby"))
+
+	total := len(items)
+
+	top := h.s.PageSize
+	if v, ok := intParam(q, "$top"); ok {
+		top = v
+	}
+	skip, _ := intParam(q, "$skip")
+
+	page := items
+	if skip < len(page) {
+		page = page[skip:]
+	} else {
+		page = nil
+	}
+	more := top > 0 && len(page) > top
+	if more {
+		page = page[:top]
+	}
+
+	values := make([]interface{}, 0, len(page))
+	for _, it := range page {
+		values = append(values, shape(it, q))
+	}
+
+	response := map[string]interface{}{
+		"@odata.context": h.base + "/$metadata",
+		"value":          values,
+	}
+	if q.Get("$count") == "true" {
+		response["@odata.count"] = total
+	}
+	if more {
+		next := url.Values{}
+		for k, v := range q {
+			next[k] = v
+		}
+		next.Set("$top", itoa(top))
+		next.Set("$skip", itoa(skip+top))
+		response["@odata.nextLink"] = "http://" + h.r.Host + h.r.URL.Path + "?" + next.Encode()
+	}
+
+	writeJSON(h.w, http.StatusOK, response)
+}
+
+// item writes a single entity after applying $select and $expand
+// pageLevels removes level and order from page items, unless asked for
+// with pagelevel=true where Graph returns them, for the pages of one
+// section and for one page
+func (h *handler) pageLevels(items []item, honored bool) []item {
+	if honored && h.r.URL.Query().Get("pagelevel") == "true" {
+		return items
+	}
+	for i, it := range items {
+		items[i] = it.without("level", "order")
+	}
+	return items
+}
+
+func (h *handler) item(it item) {
+	writeJSON(h.w, http.StatusOK, shape(it, h.r.URL.Query()))
+}
+
+func (h *handler) notFound() {
+	writeError(h.w, http.StatusNotFound, "20102",
+		"The specified resource ID does not exist.")
+}
+
+func (h *handler) methodNotAllowed() {
+	writeError(h.w, http.StatusMethodNotAllowed, "MethodNotAllowed",
+		"The method is not allowed for this resource.")
+}
+
+// createPage creates a page from a text/html or multipart/form-data body
+func (h *handler) createPage(sectionID string) {
+	if sectionID == "" {
+		h.notFound()
+		return
+	}
+
+	mediaType, params, err := mime.ParseMediaType(h.r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(h.w, http.StatusUnsupportedMediaType, "20001", "Missing Content-Type.")
+		return
+	}
+
+	var presentation []byte
+	parts := make(map[string]*resource)
+
+	switch mediaType {
+	case "text/html", "application/xhtml+xml":
+		presentation, err = io.ReadAll(h.r.Body)
+	case "multipart/form-data":
+		presentation, err = readMultipart(h.r.Body, params["boundary"], parts)
+	default:
+		writeError(h.w, http.StatusUnsupportedMediaType, "20001",
+			"Unsupported Content-Type "+mediaType+".")
+		return
+	}
+	if err != nil {
+		writeError(h.w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	// store the binary parts as resources
+	partURLs := make(map[string]string)
+	for name, res := range parts {
+		id := h.s.addResource(res.contentType, res.data)
+		partURLs[name] = h.base + "/resources/" + id + "/$value"
+	}
+
+	doc, err := parseInput(presentation, partURLs)
+	if err != nil {
+		writeError(h.w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	p := h.s.addPage(sectionID, doc.title, doc.body)
+	if doc.created != "" {
+		p.CreatedDateTime = doc.created
+		p.content = outputHTML(doc.title, doc.created, doc.body)
+	}
+
+	writeJSON(h.w, http.StatusCreated, h.s.pageItem(h.base, p).without(expandable...))
+}
+
+// readMultipart returns the Presentation part and stores the other parts
+func readMultipart(body io.Reader, boundary string, parts map[string]*resource) ([]byte, error) {
+	var presentation []byte
+
+	mr := multipart.NewReader(body, boundary)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		name := part.FormName()
+		if name == "Presentation" {
+			presentation = data
+			continue
+		}
+		parts[name] = &resource{contentType: part.Header.Get("Content-Type"), data: data}
+	}
+
+	if presentation == nil {
+		return nil, errMissingPresentation
+	}
+	return presentation, nil
+}
+
+// patchPage applies the JSON patch commands to the page content
+func (h *handler) patchPage(p *page) {
+	var commands []patchCommand
+	if err := json.NewDecoder(h.r.Body).Decode(&commands); err != nil {
+		writeError(h.w, http.StatusBadRequest, "BadRequest", err.Error())
+		return
+	}
+
+	content, title, err := applyPatch(p.content, commands)
+	if err != nil {
+		writeError(h.w, http.StatusBadRequest, "19999", err.Error())
+		return
+	}
+
+	now := h.s.now()
+	p.content = content
+	if title != "" {
+		p.Title = title
+	}
+	p.LastModifiedDateTime = now
+	h.s.touchSection(p.sectionID, now)
+
+	h.w.WriteHeader(http.StatusNoContent)
+}
+
+// deletePage removes a page, the caller must hold s.mu
+func (s *Server) deletePage(id string) {
+	for i, p := range s.pages {
+		if p.Id == id {
+			s.pages = append(s.pages[:i], s.pages[i+1:]...)
+			s.touchSection(p.sectionID, s.now())
+			return
+		}
+	}
+}
+
+// defaultSectionID returns the section for pages created without one
+func (s *Server) defaultSectionID() string {
+	for _, sec := range s.sections {
+		if sec.IsDefault {
+			return sec.Id
+		}
+	}
+	return ""
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response in the format used by Graph
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,301 @@
+package onenotetest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var errMissingPresentation = errors.New("missing Presentation part")
+
+// expandable are the navigation properties only returned with $expand
+var expandable = []string{
+	"parentNotebook", "parentSectionGroup", "parentSection",
+	"sections", "sectionGroups",
+}
+
+// item is an entity as a JSON object, with all navigation properties
+// expanded so $filter and $orderby can use them
+type item map[string]interface{}
+
+// toItem converts v to a JSON object
+func toItem(v interface{}) item {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	var it item
+	if err := json.Unmarshal(b, &it); err != nil {
+		panic(err)
+	}
+	return it
+}
+
+// without returns a copy of the item without the given keys
+func (it item) without(keys ...string) item {
+	result := make(item, len(it))
+	for k, v := range it {
+		result[k] = v
+	}
+	for _, k := range keys {
+		delete(result, k)
+	}
+	return result
+}
+
+// links returns the links of an entity, the client link opens the
+// OneNote application and the web link opens OneNote on the web
+func links(host, kind, id string) map[string]interface{} {
+	web := host + "/web/" + kind + "/" + id
+	return map[string]interface{}{
+		"oneNoteClientUrl": map[string]interface{}{"href": "onenote:" + web},
+		"oneNoteWebUrl":    map[string]interface{}{"href": web},
+	}
+}
+
+// hostOf returns the scheme and host of base
+func hostOf(base string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func (s *Server) notebookBase(base string, nb *notebook) item {
+	it := toItem(nb.Notebook)
+	self := base + "/notebooks/" + nb.Id
+	it["self"] = self
+	it["sectionsUrl"] = self + "/sections"
+	it["sectionGroupsUrl"] = self + "/sectionGroups"
+	it["links"] = links(hostOf(base), "notebooks", nb.Id)
+	return it
+}
+
+func (s *Server) sectionGroupBase(base string, g *sectionGroup) item {
+	it := toItem(g.SectionGroup).without("parentNotebook", "parentSectionGroup")
+	self := base + "/sectionGroups/" + g.Id
+	it["self"] = self
+	it["sectionsUrl"] = self + "/sections"
+	it["sectionGroupsUrl"] = self + "/sectionGroups"
+	return it
+}
+
+func (s *Server) sectionBase(base string, sec *section) item {
+	it := toItem(sec.Section).without("parentNotebook", "parentSectionGroup")
+	self := base + "/sections/" + sec.Id
+	it["self"] = self
+	it["pagesUrl"] = self + "/pages"
+	it["links"] = links(hostOf(base), "sections", sec.Id)
+	return it
+}
+
+func (s *Server) pageBase(base string, p *page) item {
+	it := toItem(p.Page).without("parentNotebook", "parentSection", "content")
+	self := base + "/pages/" + p.Id
+	it["self"] = self
+	it["contentUrl"] = self + "/content"
+	it["links"] = links(hostOf(base), "pages", p.Id)
+	return it
+}
+
+func (s *Server) notebookItem(base string, nb *notebook) item {
+	it := s.notebookBase(base, nb)
+
+	var sections, groups []interface{}
+	for _, sec := range s.sections {
+		if sec.notebookID == nb.Id && sec.groupID == "" {
+			sections = append(sections, s.sectionBase(base, sec))
+		}
+	}
+	for _, g := range s.sectionGroups {
+		if g.notebookID == nb.Id && g.parentID == "" {
+			groups = append(groups, s.sectionGroupBase(base, g))
+		}
+	}
+	it["sections"] = emptyIfNil(sections)
+	it["sectionGroups"] = emptyIfNil(groups)
+
+	return it
+}
+
+func (s *Server) sectionGroupItem(base string, g *sectionGroup) item {
+	it := s.sectionGroupBase(base, g)
+
+	if nb := s.findNotebook(g.notebookID); nb != nil {
+		it["parentNotebook"] = s.notebookBase(base, nb)
+	}
+	if parent := s.findSectionGroup(g.parentID); parent != nil {
+		it["parentSectionGroup"] = s.sectionGroupBase(base, parent)
+	} else {
+		it["parentSectionGroup"] = nil
+	}
+
+	var sections, groups []interface{}
+	for _, sec := range s.sections {
+		if sec.groupID == g.Id {
+			sections = append(sections, s.sectionBase(base, sec))
+		}
+	}
+	for _, child := range s.sectionGroups {
+		if child.parentID == g.Id {
+			groups = append(groups, s.sectionGroupBase(base, child))
+		}
+	}
+	it["sections"] = emptyIfNil(sections)
+	it["sectionGroups"] = emptyIfNil(groups)
+
+	return it
+}
+
+func (s *Server) sectionItem(base string, sec *section) item {
+	it := s.sectionBase(base, sec)
+
+	if nb := s.findNotebook(sec.notebookID); nb != nil {
+		it["parentNotebook"] = s.notebookBase(base, nb)
+	}
+	if g := s.findSectionGroup(sec.groupID); g != nil {
+		it["parentSectionGroup"] = s.sectionGroupBase(base, g)
+	} else {
+		it["parentSectionGroup"] = nil
+	}
+
+	return it
+}
+
+func (s *Server) pageItem(base string, p *page) item {
+	it := s.pageBase(base, p)
+
+	if sec := s.findSection(p.sectionID); sec != nil {
+		it["parentSection"] = s.sectionBase(base, sec)
+		if nb := s.findNotebook(sec.notebookID); nb != nil {
+			it["parentNotebook"] = s.notebookBase(base, nb)
+		}
+	}
+
+	return it
+}
+
+func (s *Server) notebookItems(base string, keep func(*notebook) bool) []item {
+	var items []item
+	for _, nb := range s.notebooks {
+		if keep == nil || keep(nb) {
+			items = append(items, s.notebookItem(base, nb))
+		}
+	}
+	return items
+}
+
+func (s *Server) sectionGroupItems(base string, keep func(*sectionGroup) bool) []item {
+	var items []item
+	for _, g := range s.sectionGroups {
+		if keep(g) {
+			items = append(items, s.sectionGroupItem(base, g))
+		}
+	}
+	return items
+}
+
+func (s *Server) sectionItems(base string, keep func(*section) bool) []item {
+	var items []item
+	for _, sec := range s.sections {
+		if keep(sec) {
+			items = append(items, s.sectionItem(base, sec))
+		}
+	}
+	return items
+}
+
+func (s *Server) pageItems(base string, keep func(*page) bool) []item {
+	var items []item
+	for _, p := range s.pages {
+		if keep(p) {
+			items = append(items, s.pageItem(base, p))
+		}
+	}
+	return items
+}
+
+// emptyIfNil returns an empty JSON array instead of null
+func emptyIfNil(v []interface{}) []interface{} {
+	if v == nil {
+		return []interface{}{}
+	}
+	return v
+}
+
+// shape applies $expand and $select to an item
+func shape(it item, q url.Values) item {
+	expand := make(map[string]bool)
+	for _, name := range splitList(q.Get("$expand")) {
+		// ignore nested options such as sections($select=id)
+		if i := strings.Index(name, "("); i >= 0 {
+			name = name[:i]
+		}
+		expand[name] = true
+	}
+
+	var drop []string
+	for _, name := range expandable {
+		if !expand[name] {
+			drop = append(drop, name)
+		}
+	}
+	result := it.without(drop...)
+
+	selected := splitList(q.Get("$select"))
+	if len(selected) == 0 {
+		return result
+	}
+
+	keep := make(map[string]bool)
+	for _, name := range selected {
+		keep[name] = true
+	}
+	for k := range result {
+		if !keep[k] && !expand[k] && !strings.HasPrefix(k, "@odata.") {
+			delete(result, k)
+		}
+	}
+	return result
+}
+
+// splitList splits a comma separated query option
+func splitList(s string) []string {
+	var result []string
+	depth := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		result = append(result, last)
+	}
+	return result
+}
+
+// intParam returns the integer value of a query option
+func intParam(q url.Values, name string) (int, bool) {
+	v, err := strconv.Atoi(q.Get(name))
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return v, true
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
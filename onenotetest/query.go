@@ -0,0 +1,413 @@
+package onenotetest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// filterItems returns the items matching the $filter expression.
+// Supported are eq, ne, gt, ge, lt, le, and, or, not, parentheses and
+// the contains, startswith and endswith functions, on properties such
+// as title or parentNotebook/displayName. String comparisons ignore case.
+func filterItems(items []item, filter string) ([]item, error) {
+	if strings.TrimSpace(filter) == "" {
+		return items, nil
+	}
+
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("invalid $filter near %q", p.tokens[p.pos].text)
+	}
+
+	var result []item
+	for _, it := range items {
+		if expr(it) {
+			result = append(result, it)
+		}
+	}
+	return result, nil
+}
+
+// orderItems sorts the items by the $orderby option,
+// such as "parentSection/displayName,title desc"
+func orderItems(items []item, orderby string) {
+	type key struct {
+		path string
+		desc bool
+	}
+
+	var keys []key
+	for _, part := range splitList(orderby) {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		k := key{path: fields[0]}
+		if len(fields) > 1 && strings.EqualFold(fields[1], "desc") {
+			k.desc = true
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, k := range keys {
+			c := compare(lookup(items[i], k.path), lookup(items[j], k.path))
+			if c == 0 {
+				continue
+			}
+			if k.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// lookup returns the value of a property path such as
+// parentNotebook/displayName
+func lookup(it item, path string) interface{} {
+	var v interface{} = map[string]interface{}(it)
+	for _, name := range strings.Split(path, "/") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			if i, ok := v.(item); ok {
+				m = i
+			} else {
+				return nil
+			}
+		}
+		v = m[name]
+	}
+	return v
+}
+
+// compare orders two JSON values, numbers numerically and other
+// values as case-insensitive strings, nil sorts first
+func compare(a, b interface{}) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		}
+		return 1
+	}
+
+	af, aok := a.(float64)
+	bf, bok := b.(float64)
+	if aok && bok {
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	}
+
+	return strings.Compare(strings.ToLower(fmt.Sprint(a)), strings.ToLower(fmt.Sprint(b)))
+}
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokString
+	tokNumber
+	tokOpen
+	tokClose
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+// tokenize splits a $filter expression into tokens
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokOpen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokClose, ")"})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ","})
+			i++
+		case c == '\'':
+			// strings are quoted with ' and a quote is written as ''
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(s) {
+					return nil, fmt.Errorf("unterminated string in $filter")
+				}
+				if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						b.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteByte(s[i])
+				i++
+			}
+			tokens = append(tokens, token{tokString, b.String()})
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\r\n(),'", rune(s[i])) {
+				i++
+			}
+			text := s[start:i]
+			kind := tokIdent
+			if _, err := strconv.ParseFloat(text, 64); err == nil {
+				kind = tokNumber
+			}
+			tokens = append(tokens, token{kind, text})
+		}
+	}
+
+	return tokens, nil
+}
+
+// predicate reports whether an item matches
+type predicate func(item) bool
+
+// parser is a recursive descent parser for $filter
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) next() (token, error) {
+	t, ok := p.peek()
+	if !ok {
+		return token{}, fmt.Errorf("unexpected end of $filter")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *parser) keyword(word string) bool {
+	t, ok := p.peek()
+	if ok && t.kind == tokIdent && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind, text string) error {
+	t, err := p.next()
+	if err != nil {
+		return err
+	}
+	if t.kind != kind {
+		return fmt.Errorf("expected %q in $filter, found %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(it item) bool { return l(it) || right(it) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(it item) bool { return l(it) && right(it) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (predicate, error) {
+	if p.keyword("not") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(it item) bool { return !expr(it) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (predicate, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.kind == tokOpen {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(tokClose, ")")
+	}
+
+	if t.kind != tokIdent {
+		return nil, fmt.Errorf("unexpected %q in $filter", t.text)
+	}
+
+	// string functions
+	if next, ok := p.peek(); ok && next.kind == tokOpen {
+		return p.parseFunction(strings.ToLower(t.text))
+	}
+
+	// comparison
+	path := t.text
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+
+	var test func(int) bool
+	switch strings.ToLower(op.text) {
+	case "eq":
+		test = func(c int) bool { return c == 0 }
+	case "ne":
+		test = func(c int) bool { return c != 0 }
+	case "gt":
+		test = func(c int) bool { return c > 0 }
+	case "ge":
+		test = func(c int) bool { return c >= 0 }
+	case "lt":
+		test = func(c int) bool { return c < 0 }
+	case "le":
+		test = func(c int) bool { return c <= 0 }
+	default:
+		return nil, fmt.Errorf("unsupported operator %q in $filter", op.text)
+	}
+
+	return func(it item) bool {
+		return test(compare(lookup(it, path), value))
+	}, nil
+}
+
+func (p *parser) parseFunction(name string) (predicate, error) {
+	var match func(s, sub string) bool
+	switch name {
+	case "contains":
+		match = strings.Contains
+	case "startswith":
+		match = strings.HasPrefix
+	case "endswith":
+		match = strings.HasSuffix
+	default:
+		return nil, fmt.Errorf("unsupported function %q in $filter", name)
+	}
+
+	if err := p.expect(tokOpen, "("); err != nil {
+		return nil, err
+	}
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	path := t.text
+	if err := p.expect(tokComma, ","); err != nil {
+		return nil, err
+	}
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokClose, ")"); err != nil {
+		return nil, err
+	}
+
+	sub := strings.ToLower(fmt.Sprint(value))
+	return func(it item) bool {
+		v := lookup(it, path)
+		if v == nil {
+			return false
+		}
+		return match(strings.ToLower(fmt.Sprint(v)), sub)
+	}, nil
+}
+
+// parseLiteral returns a string, number, boolean or nil value
+func (p *parser) parseLiteral() (interface{}, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.kind {
+	case tokString:
+		return t.text, nil
+	case tokNumber:
+		f, _ := strconv.ParseFloat(t.text, 64)
+		return f, nil
+	case tokIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		// unquoted values such as dates
+		return t.text, nil
+	}
+
+	return nil, fmt.Errorf("unexpected %q in $filter", t.text)
+}
@@ -0,0 +1,373 @@
+// Package onenotetest provides an in-memory fake of the Microsoft Graph
+// OneNote API for testing code that uses the onenote package offline.
+//
+// A Server holds notebooks, section groups, sections, pages and resources
+// and answers list, get, content, create, patch and delete requests with
+// $top, $skip, $count, @odata.nextLink paging and simple $filter,
+// $orderby, $expand and $select support. Like Graph, page levels and
+// order are only returned for one section or page with pagelevel=true.
+// Faults such as 429 and 500 responses can be injected.
+//
+//	srv := onenotetest.NewServer()
+//	defer srv.Close()
+//	nb := srv.AddNotebook("Work")
+//	sec := srv.AddSection(nb.Id, "Meetings")
+//	srv.AddPage(sec.Id, "Monday", "<p>agenda</p>")
+//	client := srv.Client()
+//
+// A Recorder records exchanges with the real API once, without access
+// tokens and user identities, and replays them from testdata so tests
+// can parse responses captured from real accounts.
+//
+//	rec, err := onenotetest.OpenCassette("list-pages", nil)
+//	...
+//	defer rec.Close()
+//	client.HTTPClient = rec.HTTPClient()
+package onenotetest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnixon67/onenote"
+)
+
+// Token is the access token used by the Client of the Server
+const Token = "onenotetest-token"
+
+// DefaultPageSize is the number of items returned without $top
+const DefaultPageSize = 20
+
+// Server is a fake Microsoft Graph OneNote API
+type Server struct {
+	*httptest.Server
+
+	// PageSize is the number of items returned without $top
+	PageSize int
+
+	// Now returns the current time for timestamps, time.Now if nil
+	Now func() time.Time
+
+	mu            sync.Mutex
+	lastID        int
+	notebooks     []*notebook
+	sectionGroups []*sectionGroup
+	sections      []*section
+	pages         []*page
+	resources     map[string]*resource
+	faults        []*Fault
+	requests      int
+}
+
+type notebook struct {
+	onenote.Notebook
+}
+
+type sectionGroup struct {
+	onenote.SectionGroup
+	notebookID string
+	parentID   string
+}
+
+type section struct {
+	onenote.Section
+	notebookID string
+	groupID    string
+}
+
+type page struct {
+	onenote.Page
+	sectionID string
+	content   string
+}
+
+type resource struct {
+	contentType string
+	data        []byte
+}
+
+// NewServer starts and returns a new empty Server,
+// the caller should call Close when finished
+func NewServer() *Server {
+	s := &Server{
+		PageSize:  DefaultPageSize,
+		resources: make(map[string]*resource),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// BaseURL returns the versioned root of the fake API
+func (s *Server) BaseURL() string {
+	return s.URL + "/" + onenote.V1
+}
+
+// Client returns a onenote.Client for the notebooks of "me" on the Server
+func (s *Server) Client() *onenote.Client {
+	c := onenote.NewClient([]byte(Token))
+	c.BaseURL = s.BaseURL()
+	c.HTTPClient = s.Server.Client()
+	return c
+}
+
+// Requests returns the number of requests served, including faults
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// now returns the current time formatted like Graph timestamps
+func (s *Server) now() string {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	return now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// newID returns a new unique id with the given prefix,
+// the caller must hold s.mu
+func (s *Server) newID(prefix string) string {
+	s.lastID++
+	return fmt.Sprintf("%s-%d", prefix, s.lastID)
+}
+
+// AddNotebook adds a notebook and returns a copy of it
+func (s *Server) AddNotebook(name string) onenote.Notebook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	nb := &notebook{onenote.Notebook{
+		Id:                   s.newID("notebook"),
+		DisplayName:          name,
+		CreatedDateTime:      now,
+		LastModifiedDateTime: now,
+		UserRole:             "Owner",
+	}}
+	nb.IsDefault = len(s.notebooks) == 0
+	s.notebooks = append(s.notebooks, nb)
+
+	return nb.Notebook
+}
+
+// AddSectionGroup adds a section group to the notebook or section group
+// with id parentID and returns a copy of it
+func (s *Server) AddSectionGroup(parentID, name string) onenote.SectionGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notebookID, groupID := s.parent(parentID)
+
+	now := s.now()
+	g := &sectionGroup{
+		SectionGroup: onenote.SectionGroup{
+			Id:                   s.newID("sectiongroup"),
+			DisplayName:          name,
+			CreatedDateTime:      now,
+			LastModifiedDateTime: now,
+		},
+		notebookID: notebookID,
+		parentID:   groupID,
+	}
+	s.sectionGroups = append(s.sectionGroups, g)
+
+	return g.SectionGroup
+}
+
+// AddSection adds a section to the notebook or section group with id
+// parentID and returns a copy of it
+func (s *Server) AddSection(parentID, name string) onenote.Section {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notebookID, groupID := s.parent(parentID)
+
+	now := s.now()
+	sec := &section{
+		Section: onenote.Section{
+			Id:                   s.newID("section"),
+			DisplayName:          name,
+			CreatedDateTime:      now,
+			LastModifiedDateTime: now,
+		},
+		notebookID: notebookID,
+		groupID:    groupID,
+	}
+	sec.IsDefault = len(s.sections) == 0
+	s.sections = append(s.sections, sec)
+
+	return sec.Section
+}
+
+// parent returns the notebook and section group ids for the parent
+// with the given id, it panics if there is no such parent
+func (s *Server) parent(id string) (notebookID, groupID string) {
+	if nb := s.findNotebook(id); nb != nil {
+		return nb.Id, ""
+	}
+	if g := s.findSectionGroup(id); g != nil {
+		return g.notebookID, g.Id
+	}
+	panic("onenotetest: no notebook or section group " + id)
+}
+
+// AddPage adds a page to the section with the given id and returns a
+// copy of it. body is the HTML inside the body element of the page.
+func (s *Server) AddPage(sectionID, title, body string) onenote.Page {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findSection(sectionID) == nil {
+		panic("onenotetest: no section " + sectionID)
+	}
+
+	p := s.addPage(sectionID, title, body)
+	return p.Page
+}
+
+// addPage adds a page, the caller must hold s.mu
+func (s *Server) addPage(sectionID, title, body string) *page {
+	now := s.now()
+	p := &page{
+		Page: onenote.Page{
+			Id:                   s.newID("page"),
+			Title:                title,
+			CreatedDateTime:      now,
+			LastModifiedDateTime: now,
+			CreatedByAppId:       "onenotetest",
+			Order:                int32(len(s.pagesIn(sectionID))),
+		},
+		sectionID: sectionID,
+	}
+	p.content = outputHTML(title, now, body)
+	s.pages = append(s.pages, p)
+
+	s.touchSection(sectionID, now)
+
+	return p
+}
+
+// SetPageLevel sets the indentation level of a page, subpages have a
+// level greater than the page above them
+func (s *Server) SetPageLevel(id string, level int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p := s.findPage(id); p != nil {
+		p.Level = level
+	}
+}
+
+// PageContent returns the current HTML content of the page
+func (s *Server) PageContent(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := s.findPage(id)
+	if p == nil {
+		return "", false
+	}
+	return p.content, true
+}
+
+// AddResource adds an image or file resource and returns its id,
+// the data is served at resources/{id}/$value
+func (s *Server) AddResource(contentType string, data []byte) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addResource(contentType, data)
+}
+
+// addResource adds a resource, the caller must hold s.mu
+func (s *Server) addResource(contentType string, data []byte) string {
+	id := s.newID("resource")
+	s.resources[id] = &resource{contentType: contentType, data: data}
+	return id
+}
+
+// touchSection updates the modified time of a section and its parents,
+// the caller must hold s.mu
+func (s *Server) touchSection(id, now string) {
+	sec := s.findSection(id)
+	if sec == nil {
+		return
+	}
+	sec.LastModifiedDateTime = now
+	if nb := s.findNotebook(sec.notebookID); nb != nil {
+		nb.LastModifiedDateTime = now
+	}
+}
+
+func (s *Server) findNotebook(id string) *notebook {
+	for _, nb := range s.notebooks {
+		if nb.Id == id {
+			return nb
+		}
+	}
+	return nil
+}
+
+func (s *Server) findSectionGroup(id string) *sectionGroup {
+	for _, g := range s.sectionGroups {
+		if g.Id == id {
+			return g
+		}
+	}
+	return nil
+}
+
+func (s *Server) findSection(id string) *section {
+	for _, sec := range s.sections {
+		if sec.Id == id {
+			return sec
+		}
+	}
+	return nil
+}
+
+func (s *Server) findPage(id string) *page {
+	for _, p := range s.pages {
+		if p.Id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// pagesIn returns the pages of a section, the caller must hold s.mu
+func (s *Server) pagesIn(sectionID string) []*page {
+	var result []*page
+	for _, p := range s.pages {
+		if p.sectionID == sectionID {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// outputHTML returns the page HTML as Graph returns it for a page
+func outputHTML(title, created, body string) string {
+	var b strings.Builder
+	b.WriteString("<html lang=\"en-US\">\n<head>\n")
+	b.WriteString("<title>" + escapeText(title) + "</title>\n")
+	b.WriteString("<meta http-equiv=\"Content-Type\" content=\"text/html; charset=utf-8\" />\n")
+	b.WriteString("<meta name=\"created\" content=\"" + created + "\" />\n")
+	b.WriteString("</head>\n")
+	b.WriteString("<body data-absolute-enabled=\"true\" style=\"font-family:Calibri;font-size:11pt\">\n")
+	b.WriteString(body)
+	b.WriteString("\n</body>\n</html>\n")
+	return b.String()
+}
+
+// escapeText escapes text for HTML
+func escapeText(s string) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+	return r.Replace(s)
+}
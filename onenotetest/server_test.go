@@ -0,0 +1,410 @@
+package onenotetest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+// newServer returns a Server with a notebook, a default section and
+// n pages titled "Page 1" to "Page n"
+func newServer(t *testing.T, n int) (*onenotetest.Server, onenote.Section) {
+	t.Helper()
+
+	srv := onenotetest.NewServer()
+	t.Cleanup(srv.Close)
+
+	nb := srv.AddNotebook("Work")
+	sec := srv.AddSection(nb.Id, "Meetings")
+	for i := 1; i <= n; i++ {
+		srv.AddPage(sec.Id, "Page "+string(rune('0'+i)), "<p>page</p>")
+	}
+	return srv, sec
+}
+
+func TestPaging(t *testing.T) {
+	tests := []struct {
+		name         string
+		pageSize     int
+		query        url.Values
+		wantTitles   int
+		wantRequests int
+		wantCount    int
+	}{
+		{"one set", 20, nil, 5, 1, 0},
+		{"page size", 2, nil, 5, 3, 0},
+		{"top", 20, url.Values{"$top": {"4"}}, 5, 2, 0},
+		{"skip", 2, url.Values{"$skip": {"3"}}, 2, 1, 0},
+		{"count", 2, url.Values{"$count": {"true"}}, 5, 3, 5},
+		{"filter", 2, url.Values{"$filter": {"title eq 'Page 3'"}}, 1, 1, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newServer(t, 5)
+			srv.PageSize = tt.pageSize
+
+			it := srv.Client().Pages(tt.query)
+			pages, err := it.All(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pages) != tt.wantTitles {
+				t.Errorf("got %d pages, want %d", len(pages), tt.wantTitles)
+			}
+			if srv.Requests() != tt.wantRequests {
+				t.Errorf("Requests() = %d, want %d", srv.Requests(), tt.wantRequests)
+			}
+			if it.Count != tt.wantCount {
+				t.Errorf("Count = %d, want %d", it.Count, tt.wantCount)
+			}
+
+			seen := make(map[string]bool)
+			for _, p := range pages {
+				if seen[p.Id] {
+					t.Errorf("page %s returned twice", p.Id)
+				}
+				seen[p.Id] = true
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		fault      *onenotetest.Fault
+		call       func(*onenote.Client) error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "page not found",
+			call: func(c *onenote.Client) error {
+				_, err := c.GetPage(context.Background(), "missing", nil)
+				return err
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   "20102",
+		},
+		{
+			name: "bad filter",
+			call: func(c *onenote.Client) error {
+				_, err := c.ListPages(context.Background(), url.Values{"$filter": {"title eq"}})
+				return err
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "BadRequest",
+		},
+		{
+			name:  "throttled",
+			fault: &onenotetest.Fault{Status: http.StatusTooManyRequests, RetryAfter: time.Second},
+			call: func(c *onenote.Client) error {
+				_, err := c.ListNotebooks(context.Background(), nil)
+				return err
+			},
+			wantStatus: http.StatusTooManyRequests,
+			wantCode:   "20166",
+		},
+		{
+			name:  "fault on path",
+			fault: &onenotetest.Fault{Path: "/pages", Status: http.StatusServiceUnavailable},
+			call: func(c *onenote.Client) error {
+				_, err := c.ListPages(context.Background(), nil)
+				return err
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantCode:   "serviceNotAvailable",
+		},
+		{
+			name:  "fault on other path",
+			fault: &onenotetest.Fault{Path: "/pages", Status: http.StatusServiceUnavailable},
+			call: func(c *onenote.Client) error {
+				_, err := c.ListNotebooks(context.Background(), nil)
+				return err
+			},
+		},
+		{
+			name: "patch missing target",
+			call: func(c *onenote.Client) error {
+				pages, err := c.ListPages(context.Background(), nil)
+				if err != nil {
+					return err
+				}
+				return c.UpdatePage(context.Background(), pages.Value[0].Id, []onenote.PatchCommand{
+					{Target: "#missing", Action: "append", Content: "<p>x</p>"},
+				})
+			},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "19999",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newServer(t, 1)
+			if tt.fault != nil {
+				srv.InjectFault(*tt.fault)
+			}
+
+			err := tt.call(srv.Client())
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("error = %v, want nil", err)
+				}
+				return
+			}
+
+			var apiErr *onenote.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *onenote.Error", err)
+			}
+			if apiErr.StatusCode != tt.wantStatus || apiErr.Code != tt.wantCode {
+				t.Errorf("error = %d %s, want %d %s", apiErr.StatusCode, apiErr.Code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestFaultTimes(t *testing.T) {
+	srv, _ := newServer(t, 0)
+	srv.InjectFault(onenotetest.Fault{Status: http.StatusInternalServerError, Times: 2})
+
+	client := srv.Client()
+	for i, wantErr := range []bool{true, true, false} {
+		_, err := client.ListNotebooks(context.Background(), nil)
+		if (err != nil) != wantErr {
+			t.Errorf("request %d: error = %v, want error %v", i, err, wantErr)
+		}
+	}
+}
+
+func TestCreateUpdate(t *testing.T) {
+	tests := []struct {
+		name        string
+		html        string
+		parts       []onenote.Part
+		commands    []onenote.PatchCommand
+		wantTitle   string
+		wantContent []string
+	}{
+		{
+			name:        "html",
+			html:        "<html><head><title>Notes</title></head><body><p>first</p></body></html>",
+			wantTitle:   "Notes",
+			wantContent: []string{"<title>Notes</title>", "<p>first</p>"},
+		},
+		{
+			name: "parts",
+			html: `<html><head><title>Image</title></head><body><img src="name:image1" alt="chart" /></body></html>`,
+			parts: []onenote.Part{
+				{Name: "image1", ContentType: "image/png", Data: []byte("png")},
+			},
+			wantTitle:   "Image",
+			wantContent: []string{`alt="chart"`, "/resources/"},
+		},
+		{
+			name: "append and title",
+			html: `<html><head><title>Old</title></head><body><p data-id="p1">first</p></body></html>`,
+			commands: []onenote.PatchCommand{
+				{Target: "title", Action: "replace", Content: "New"},
+				{Target: "#p1", Action: "insert", Position: "after", Content: "<p>second</p>"},
+				{Target: "body", Action: "append", Content: "<p>last</p>"},
+			},
+			wantTitle:   "New",
+			wantContent: []string{"<title>New</title>", "first</p><p>second</p>", "<p>last</p>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, sec := newServer(t, 0)
+			client := srv.Client()
+			ctx := context.Background()
+
+			page, err := client.CreatePage(ctx, sec.Id, tt.html, tt.parts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.commands != nil {
+				if err := client.UpdatePage(ctx, page.Id, tt.commands); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := client.GetPage(ctx, page.Id, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != tt.wantTitle {
+				t.Errorf("Title = %q, want %q", got.Title, tt.wantTitle)
+			}
+
+			content, err := client.GetPageContent(ctx, page.Id, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.wantContent {
+				if !strings.Contains(content, want) {
+					t.Errorf("content %q does not contain %q", content, want)
+				}
+			}
+
+			if err := client.DeletePage(ctx, page.Id); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.GetPage(ctx, page.Id, nil); err == nil {
+				t.Error("GetPage() after DeletePage() error = nil")
+			}
+		})
+	}
+}
+
+func TestCreateMissingPart(t *testing.T) {
+	srv, sec := newServer(t, 0)
+
+	_, err := srv.Client().CreatePage(context.Background(), sec.Id,
+		`<html><head><title>x</title></head><body><img src="name:image1" /></body></html>`,
+		onenote.Part{Name: "image2", ContentType: "image/png", Data: []byte("png")})
+
+	var apiErr *onenote.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("CreatePage() error = %v, want 400", err)
+	}
+}
+
+func TestJSONNames(t *testing.T) {
+	srv, sec := newServer(t, 1)
+	srv.AddSectionGroup(srv.AddNotebook("Home").Id, "Projects")
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/me/onenote/notebooks", []string{
+			"id", "self", "displayName", "createdDateTime", "lastModifiedDateTime",
+			"isDefault", "userRole", "isShared", "sectionsUrl", "sectionGroupsUrl",
+			"links.oneNoteClientUrl.href", "links.oneNoteWebUrl.href",
+		}},
+		{"/me/onenote/sectionGroups?$expand=parentNotebook", []string{
+			"id", "self", "displayName", "sectionsUrl", "sectionGroupsUrl",
+			"parentNotebook.id",
+		}},
+		{"/me/onenote/sections?$expand=parentNotebook", []string{
+			"id", "self", "displayName", "isDefault", "pagesUrl",
+			"links.oneNoteWebUrl.href", "parentNotebook.displayName",
+		}},
+		{"/me/onenote/pages?$expand=parentSection", []string{
+			"id", "self", "title", "createdDateTime", "lastModifiedDateTime",
+			"contentUrl", "links.oneNoteClientUrl.href", "parentSection.id",
+		}},
+		{"/me/onenote/sections/" + sec.Id + "/pages?pagelevel=true", []string{
+			"id", "title", "level", "order",
+		}},
+		{"/me/onenote/pages?$select=id,title", []string{"id", "title"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.BaseURL()+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+onenotetest.Token)
+			resp, err := srv.Server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var body struct {
+				Value []map[string]interface{} `json:"value"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Value) == 0 {
+				t.Fatal("no items")
+			}
+
+			for _, name := range tt.want {
+				if !hasField(body.Value[0], name) {
+					t.Errorf("missing field %s in %v", name, body.Value[0])
+				}
+			}
+			if strings.Contains(tt.path, "$select") && len(body.Value[0]) != len(tt.want) {
+				t.Errorf("got fields %v, want only %v", body.Value[0], tt.want)
+			}
+		})
+	}
+}
+
+// hasField reports whether the object has the dotted field path
+func hasField(obj map[string]interface{}, path string) bool {
+	name, rest, nested := strings.Cut(path, ".")
+	v, ok := obj[name]
+	if !ok || !nested {
+		return ok
+	}
+	child, ok := v.(map[string]interface{})
+	return ok && hasField(child, rest)
+}
+
+func TestPageLevel(t *testing.T) {
+	srv, sec := newServer(t, 1)
+	page := srv.AddPage(sec.Id, "Sub", "<p>sub</p>")
+	srv.SetPageLevel(page.Id, 1)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/me/onenote/sections/" + sec.Id + "/pages?pagelevel=true", true},
+		{"/me/onenote/sections/" + sec.Id + "/pages", false},
+		{"/me/onenote/pages/" + page.Id + "?pagelevel=true", true},
+		{"/me/onenote/pages/" + page.Id, false},
+		// Graph ignores pagelevel when listing the pages of all sections
+		{"/me/onenote/pages?pagelevel=true", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, srv.BaseURL()+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+onenotetest.Token)
+			resp, err := srv.Server.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			var body struct {
+				Value []map[string]interface{} `json:"value"`
+				Level *int                     `json:"level"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+
+			got := body.Level != nil
+			for _, v := range body.Value {
+				_, hasLevel := v["level"]
+				_, hasOrder := v["order"]
+				got = got || hasLevel || hasOrder
+			}
+			if got != tt.want {
+				t.Errorf("level and order returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package onenote
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// Part is binary data sent with the HTML of a page, such as an image or
+// an attached file. The HTML refers to it as "name:" and the Name, for
+// example <img src="name:image1" />.
+type Part struct {
+	// Name of the part, letters and digits only
+	Name string
+
+	// ContentType of Data, such as "image/png" or "application/pdf"
+	ContentType string
+
+	// Data is the content of the part
+	Data []byte
+}
+
+// multipartBody returns a multipart/form-data body with the first part
+// named name, such as Presentation, followed by the binary parts
+func multipartBody(name, contentType string, data []byte, parts []Part) (string, []byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	seen := map[string]bool{name: true}
+	if err := writePart(w, Part{Name: name, ContentType: contentType, Data: data}); err != nil {
+		return "", nil, err
+	}
+
+	for _, part := range parts {
+		if part.Name == "" || !isPartName(part.Name) {
+			return "", nil, fmt.Errorf("onenote: invalid part name %q", part.Name)
+		}
+		if seen[part.Name] {
+			return "", nil, fmt.Errorf("onenote: duplicate part name %q", part.Name)
+		}
+		seen[part.Name] = true
+
+		if err := writePart(w, part); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+	return w.FormDataContentType(), body.Bytes(), nil
+}
+
+// writePart adds one part to w
+func writePart(w *multipart.Writer, part Part) error {
+	contentType := part.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, part.Name))
+	h.Set("Content-Type", contentType)
+
+	pw, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	_, err = pw.Write(part.Data)
+	return err
+}
+
+// isPartName reports whether name only has letters, digits, "-" and "_",
+// so it needs no quoting in the HTML or the part header
+func isPartName(name string) bool {
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
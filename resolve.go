@@ -0,0 +1,417 @@
+package onenote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind is the type of a OneNote object
+type Kind string
+
+// kinds of objects
+const (
+	KindNotebook     Kind = "notebook"
+	KindSectionGroup Kind = "section group"
+	KindSection      Kind = "section"
+	KindPage         Kind = "page"
+)
+
+// Resolved is the object found for a path, only the field for its Kind
+// is set
+type Resolved struct {
+	// Path is the canonical path with the names of the objects
+	Path string
+
+	Kind Kind
+	Id   string
+
+	Notebook     *Notebook
+	SectionGroup *SectionGroup
+	Section      *Section
+	Page         *Page
+}
+
+// ErrEmptyPath is returned by Resolve for a path without names
+var ErrEmptyPath = errors.New("onenote: empty path")
+
+// NotFoundError is returned by Resolve when no object has the name
+type NotFoundError struct {
+	// Parent is the path resolved so far, empty at the notebooks
+	Parent string
+
+	// Name that was not found in Parent
+	Name string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Parent == "" {
+		return fmt.Sprintf("onenote: no notebook named %q", e.Name)
+	}
+	return fmt.Sprintf("onenote: nothing named %q in %q", e.Name, e.Parent)
+}
+
+// AmbiguousError is returned by Resolve when several objects have the
+// name, for example a section and a section group, or two pages
+type AmbiguousError struct {
+	// Parent is the path resolved so far, empty at the notebooks
+	Parent string
+
+	// Name that matched more than once
+	Name string
+
+	// Matches are the objects with the name
+	Matches []Resolved
+}
+
+func (e *AmbiguousError) Error() string {
+	matches := make([]string, len(e.Matches))
+	for i, m := range e.Matches {
+		matches[i] = fmt.Sprintf("%s %s", m.Kind, m.Id)
+	}
+	return fmt.Sprintf("onenote: %q in %q is ambiguous: %s",
+		e.Name, e.Parent, strings.Join(matches, ", "))
+}
+
+// SplitPath returns the names in a path such as
+// "Notebook/Section Group/Section/Page title". A slash or backslash
+// within a name is escaped with a backslash.
+func SplitPath(path string) []string {
+	var names []string
+	var name strings.Builder
+	escaped := false
+
+	for _, r := range path {
+		switch {
+		case escaped:
+			name.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '/':
+			if name.Len() > 0 {
+				names = append(names, name.String())
+			}
+			name.Reset()
+		default:
+			name.WriteRune(r)
+		}
+	}
+	if name.Len() > 0 {
+		names = append(names, name.String())
+	}
+
+	return names
+}
+
+// JoinPath returns the path for names, escaping slashes and backslashes
+func JoinPath(names ...string) string {
+	r := strings.NewReplacer(`\`, `\\`, "/", `\/`)
+	escaped := make([]string, len(names))
+	for i, name := range names {
+		escaped[i] = r.Replace(name)
+	}
+	return strings.Join(escaped, "/")
+}
+
+// Resolve returns the notebook, section group, section or page for a
+// path of display names, such as "Work/Projects/Meetings/Monday". Names
+// match exactly, or ignoring case if no name matches exactly. Resolved
+// prefixes of the path are kept in Client.Paths if set.
+func (c *Client) Resolve(ctx context.Context, path string) (*Resolved, error) {
+	names := SplitPath(path)
+	if len(names) == 0 {
+		return nil, ErrEmptyPath
+	}
+
+	// start from the longest cached prefix
+	var parent *Resolved
+	start := 0
+	for i := len(names); i > 0 && c.Paths != nil; i-- {
+		if r, ok := c.Paths.get(c.ownerURL(), JoinPath(names[:i]...)); ok {
+			parent, start = r, i
+			break
+		}
+	}
+
+	for i := start; i < len(names); i++ {
+		name := names[i]
+		candidates, err := c.children(ctx, parent)
+		if err != nil {
+			return nil, err
+		}
+
+		// a section group and a section with the same name are told
+		// apart by the number of names after them
+		candidates = canHold(candidates, len(names)-i-1)
+
+		r, err := match(parent, name, candidates)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Path = JoinPath(r.name())
+		if parent != nil {
+			r.Path = parent.Path + "/" + r.Path
+		}
+		if c.Paths != nil {
+			c.Paths.put(c.ownerURL(), r.Path, r)
+			if requested := JoinPath(names[:i+1]...); requested != r.Path {
+				c.Paths.put(c.ownerURL(), requested, r)
+			}
+		}
+		parent = r
+	}
+
+	return parent, nil
+}
+
+// children returns the objects within parent, the notebooks if nil
+func (c *Client) children(ctx context.Context, parent *Resolved) ([]Resolved, error) {
+	return c.listChildren(ctx, parent, func(lists ...func()) {
+		for _, list := range lists {
+			list()
+		}
+	})
+}
+
+// listChildren returns the objects within parent like children, run
+// calls the functions listing the section groups and the sections and
+// returns when they are done
+func (c *Client) listChildren(ctx context.Context, parent *Resolved, run func(lists ...func())) ([]Resolved, error) {
+	var result []Resolved
+
+	if parent == nil {
+		err := c.listAll(ctx, OpListNotebooks, c.ownerURL()+"/notebooks",
+			func(body []byte) (string, error) {
+				var response NotebookResponse
+				err := json.Unmarshal(body, &response)
+				for i := range response.Value {
+					nb := response.Value[i]
+					result = append(result, Resolved{Kind: KindNotebook, Id: nb.Id, Notebook: &nb})
+				}
+				return response.ODataNextLink, err
+			})
+		return result, err
+	}
+
+	var groups []SectionGroup
+	var sections []Section
+	var groupsErr, sectionsErr error
+
+	switch parent.Kind {
+	case KindNotebook:
+		run(func() { groups, groupsErr = parent.Notebook.SectionGroups(ctx, c) },
+			func() { sections, sectionsErr = parent.Notebook.Sections(ctx, c) })
+	case KindSectionGroup:
+		run(func() { groups, groupsErr = parent.SectionGroup.SectionGroups(ctx, c) },
+			func() { sections, sectionsErr = parent.SectionGroup.Sections(ctx, c) })
+	case KindSection:
+		var pages []Page
+		var err error
+		run(func() { pages, err = parent.Section.Pages(ctx, c) })
+		for i := range pages {
+			p := pages[i]
+			result = append(result, Resolved{Kind: KindPage, Id: p.Id, Page: &p})
+		}
+		return result, err
+	}
+	if groupsErr != nil {
+		return nil, groupsErr
+	}
+	if sectionsErr != nil {
+		return nil, sectionsErr
+	}
+
+	// pages have no children
+	for i := range groups {
+		g := groups[i]
+		result = append(result, Resolved{Kind: KindSectionGroup, Id: g.Id, SectionGroup: &g})
+	}
+	for i := range sections {
+		s := sections[i]
+		result = append(result, Resolved{Kind: KindSection, Id: s.Id, Section: &s})
+	}
+
+	return result, nil
+}
+
+// listAll requests urlString and the following sets of a list, add
+// unmarshals each response body and returns its @odata.nextLink
+func (c *Client) listAll(ctx context.Context, op Operation, urlString string, add func(body []byte) (string, error)) error {
+	for urlString != "" {
+		body, err := c.get(ctx, op, urlString, nil)
+		if err != nil {
+			return err
+		}
+		if urlString, err = add(body); err != nil {
+			return fmt.Errorf("onenote: cannot unmarshal: %w", err)
+		}
+	}
+	return nil
+}
+
+// name returns the display name or title of r
+func (r *Resolved) name() string {
+	switch r.Kind {
+	case KindNotebook:
+		return r.Notebook.DisplayName
+	case KindSectionGroup:
+		return r.SectionGroup.DisplayName
+	case KindSection:
+		return r.Section.DisplayName
+	case KindPage:
+		return r.Page.Title
+	}
+	return ""
+}
+
+// canHold returns the candidates that can have depth levels below them
+func canHold(candidates []Resolved, depth int) []Resolved {
+	var result []Resolved
+	for _, r := range candidates {
+		switch {
+		case r.Kind == KindPage && depth > 0:
+		case r.Kind == KindSection && depth > 1:
+		default:
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// match returns the only candidate with name, exact matches first
+func match(parent *Resolved, name string, candidates []Resolved) (*Resolved, error) {
+	var parentPath string
+	if parent != nil {
+		parentPath = parent.Path
+	}
+
+	for _, equal := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		strings.EqualFold,
+	} {
+		var matches []Resolved
+		for _, r := range candidates {
+			if equal(r.name(), name) {
+				matches = append(matches, r)
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return &matches[0], nil
+		default:
+			return nil, &AmbiguousError{Parent: parentPath, Name: name, Matches: matches}
+		}
+	}
+
+	return nil, &NotFoundError{Parent: parentPath, Name: name}
+}
+
+// DefaultPathTTL is the default PathCache.TTL
+const DefaultPathTTL = 10 * time.Minute
+
+// PathCache keeps the objects found by Resolve in memory, keyed by owner
+// scope and path. An object is stored under its canonical path and under
+// the path it was requested by, so names differing only in case are told
+// apart like in Resolve. Page titles change more often than other names, so
+// writes through the Client remove the pages, and Invalidate removes a
+// path after an object is renamed, moved or deleted elsewhere. A
+// PathCache may be shared by Clients.
+type PathCache struct {
+	// TTL is how long a path is used, DefaultPathTTL if zero
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]pathEntry
+}
+
+// pathEntry is a resolved path and when it was stored
+type pathEntry struct {
+	owner    string
+	resolved Resolved
+	stored   time.Time
+}
+
+// NewPathCache returns a PathCache that keeps paths for ttl
+func NewPathCache(ttl time.Duration) *PathCache {
+	return &PathCache{TTL: ttl}
+}
+
+// pathKey returns the map key for owner and path
+func pathKey(owner, path string) string {
+	return owner + "\n" + path
+}
+
+func (pc *PathCache) get(owner, path string) (*Resolved, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	e, ok := pc.entries[pathKey(owner, path)]
+	if !ok {
+		return nil, false
+	}
+
+	ttl := pc.TTL
+	if ttl <= 0 {
+		ttl = DefaultPathTTL
+	}
+	if time.Since(e.stored) > ttl {
+		delete(pc.entries, pathKey(owner, path))
+		return nil, false
+	}
+
+	r := e.resolved
+	return &r, true
+}
+
+func (pc *PathCache) put(owner, path string, r *Resolved) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.entries == nil {
+		pc.entries = make(map[string]pathEntry)
+	}
+	pc.entries[pathKey(owner, path)] = pathEntry{owner: owner, resolved: *r, stored: time.Now()}
+}
+
+// remove deletes the entries for which match is true
+func (pc *PathCache) remove(match func(e pathEntry) bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	for key, e := range pc.entries {
+		if match(e) {
+			delete(pc.entries, key)
+		}
+	}
+}
+
+// Invalidate removes path and the paths below it, for all owners,
+// ignoring case
+func (pc *PathCache) Invalidate(path string) {
+	prefix := strings.ToLower(JoinPath(SplitPath(path)...))
+	pc.remove(func(e pathEntry) bool {
+		p := strings.ToLower(e.resolved.Path)
+		return p == prefix || strings.HasPrefix(p, prefix+"/")
+	})
+}
+
+// Clear removes all paths
+func (pc *PathCache) Clear() {
+	pc.remove(func(pathEntry) bool { return true })
+}
+
+// invalidatePages removes the pages of owner after a write
+func (pc *PathCache) invalidatePages(owner string) {
+	pc.remove(func(e pathEntry) bool {
+		return e.owner == owner && e.resolved.Kind == KindPage
+	})
+}
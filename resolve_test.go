@@ -0,0 +1,160 @@
+package onenote_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+func TestSplitJoinPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		names []string
+		join  string
+	}{
+		{"", nil, ""},
+		{"Work", []string{"Work"}, "Work"},
+		{"/Work//Notes/", []string{"Work", "Notes"}, "Work/Notes"},
+		{`Work/Q1\/Q2`, []string{"Work", "Q1/Q2"}, `Work/Q1\/Q2`},
+		{`Work/C:\\temp`, []string{"Work", `C:\temp`}, `Work/C:\\temp`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			names := onenote.SplitPath(tt.path)
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("SplitPath(%q) = %q, want %q", tt.path, names, tt.names)
+			}
+			if got := onenote.JoinPath(names...); got != tt.join {
+				t.Errorf("JoinPath(%q) = %q, want %q", names, got, tt.join)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	srv := onenotetest.NewServer()
+	defer srv.Close()
+
+	work := srv.AddNotebook("Work")
+	lower := srv.AddNotebook("work")
+	home := srv.AddNotebook("Home")
+	projects := srv.AddSectionGroup(work.Id, "Projects")
+	launch := srv.AddSection(projects.Id, "Launch")
+	notes := srv.AddSection(work.Id, "Notes")
+	lowerNotes := srv.AddSection(lower.Id, "Notes")
+	srv.AddSection(work.Id, "Both")
+	inGroup := srv.AddPage(srv.AddSection(srv.AddSectionGroup(work.Id, "Both").Id, "Inner").Id, "Page", "<p>page</p>")
+	monday := srv.AddPage(notes.Id, "Monday", "<p>monday</p>")
+	srv.AddPage(notes.Id, "Twice", "<p>1</p>")
+	srv.AddPage(notes.Id, "Twice", "<p>2</p>")
+	plan := srv.AddPage(launch.Id, "Plan", "<p>plan</p>")
+	srv.AddSection(home.Id, "Shopping")
+
+	var notFound *onenote.NotFoundError
+	var ambiguous *onenote.AmbiguousError
+
+	tests := []struct {
+		path     string
+		wantKind onenote.Kind
+		wantId   string
+		wantPath string
+		wantErr  interface{}
+	}{
+		{path: "Work", wantKind: onenote.KindNotebook, wantId: work.Id, wantPath: "Work"},
+		{path: "work", wantKind: onenote.KindNotebook, wantId: lower.Id, wantPath: "work"},
+		{path: "HOME", wantKind: onenote.KindNotebook, wantId: home.Id, wantPath: "Home"},
+		{path: "Work/Notes", wantKind: onenote.KindSection, wantId: notes.Id, wantPath: "Work/Notes"},
+		{path: "work/Notes", wantKind: onenote.KindSection, wantId: lowerNotes.Id, wantPath: "work/Notes"},
+		{path: "Work/Notes/monday", wantKind: onenote.KindPage, wantId: monday.Id, wantPath: "Work/Notes/Monday"},
+		{path: "Work/Projects/Launch/Plan", wantKind: onenote.KindPage, wantId: plan.Id, wantPath: "Work/Projects/Launch/Plan"},
+		{path: "Work/Both", wantErr: &ambiguous},
+		{path: "Work/Both/Inner/Page", wantKind: onenote.KindPage, wantId: inGroup.Id, wantPath: "Work/Both/Inner/Page"},
+		{path: "Work/Notes/Twice", wantErr: &ambiguous},
+		{path: "Work/Missing", wantErr: &notFound},
+		{path: "Missing", wantErr: &notFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			client := srv.Client()
+			client.Paths = onenote.NewPathCache(0)
+
+			// the second time is from the cache
+			for i := 0; i < 2; i++ {
+				r, err := client.Resolve(context.Background(), tt.path)
+				if tt.wantErr != nil {
+					if !errors.As(err, tt.wantErr) {
+						t.Fatalf("Resolve() error = %v, want %T", err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.Kind != tt.wantKind || r.Id != tt.wantId || r.Path != tt.wantPath {
+					t.Errorf("Resolve() = %s %s %q, want %s %s %q", r.Kind, r.Id, r.Path, tt.wantKind, tt.wantId, tt.wantPath)
+				}
+			}
+		})
+	}
+
+	if _, err := srv.Client().Resolve(context.Background(), "/"); !errors.Is(err, onenote.ErrEmptyPath) {
+		t.Errorf("Resolve(/) error = %v, want %v", err, onenote.ErrEmptyPath)
+	}
+}
+
+func TestPathCache(t *testing.T) {
+	srv := onenotetest.NewServer()
+	defer srv.Close()
+
+	work := srv.AddNotebook("Work")
+	lower := srv.AddNotebook("work")
+	workNotes := srv.AddSection(work.Id, "Notes")
+	lowerNotes := srv.AddSection(lower.Id, "Notes")
+	srv.AddSection(srv.AddNotebook("Home").Id, "Notes")
+
+	client := srv.Client()
+	client.Paths = onenote.NewPathCache(0)
+
+	tests := []struct {
+		path         string
+		wantSection  string
+		wantRequests bool
+	}{
+		{"Work/Notes", workNotes.Id, true},
+		{"work/Notes", lowerNotes.Id, true},
+		{"Work/Notes", workNotes.Id, false},
+		{"work/Notes", lowerNotes.Id, false},
+		{"home/Notes", "", true},
+		{"home/Notes", "", false},
+		{"Home/Notes", "", false},
+	}
+
+	for _, tt := range tests {
+		before := srv.Requests()
+		r, err := client.Resolve(context.Background(), tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.wantSection != "" && r.Id != tt.wantSection {
+			t.Errorf("%s: section = %s, want %s", tt.path, r.Id, tt.wantSection)
+		}
+		if requested := srv.Requests() > before; requested != tt.wantRequests {
+			t.Errorf("%s: requested = %v, want %v", tt.path, requested, tt.wantRequests)
+		}
+	}
+
+	client.Paths.Invalidate("HOME")
+	before := srv.Requests()
+	if _, err := client.Resolve(context.Background(), "home/Notes"); err != nil {
+		t.Fatal(err)
+	}
+	if srv.Requests() == before {
+		t.Error("Resolve() after Invalidate() was cached")
+	}
+}
@@ -0,0 +1,199 @@
+package onenote
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope is a Microsoft Graph permission for OneNote
+type Scope string
+
+// OneNote permissions, from least to most privileged
+const (
+	NotesRead         Scope = "Notes.Read"
+	NotesCreate       Scope = "Notes.Create"
+	NotesReadWrite    Scope = "Notes.ReadWrite"
+	NotesReadAll      Scope = "Notes.Read.All"
+	NotesReadWriteAll Scope = "Notes.ReadWrite.All"
+)
+
+// implied lists the scopes that also grant each scope
+var implied = map[Scope][]Scope{
+	NotesRead:         {NotesRead, NotesReadWrite, NotesReadAll, NotesReadWriteAll},
+	NotesCreate:       {NotesCreate, NotesReadWrite, NotesReadWriteAll},
+	NotesReadWrite:    {NotesReadWrite, NotesReadWriteAll},
+	NotesReadAll:      {NotesReadAll, NotesReadWriteAll},
+	NotesReadWriteAll: {NotesReadWriteAll},
+}
+
+// normalizeScope strips the resource prefix of a scope, so
+// "https://graph.microsoft.com/Notes.Read" becomes "Notes.Read"
+func normalizeScope(s string) string {
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.ToLower(s)
+}
+
+// HasScope reports whether the granted scopes include s or a scope
+// that implies s, for example Notes.ReadWrite implies Notes.Read
+func HasScope(granted []string, s Scope) bool {
+	for _, g := range granted {
+		g = normalizeScope(g)
+		for _, i := range implied[s] {
+			if g == strings.ToLower(string(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Operation identifies a OneNote API call
+type Operation string
+
+// operations of the Client
+const (
+	OpListNotebooks     Operation = "ListNotebooks"
+	OpListSectionGroups Operation = "ListSectionGroups"
+	OpListSections      Operation = "ListSections"
+	OpListPages         Operation = "ListPages"
+	OpGetPage           Operation = "GetPage"
+	OpGetPageContent    Operation = "GetPageContent"
+	OpGetResource       Operation = "GetResource"
+	OpCreatePage        Operation = "CreatePage"
+	OpUpdatePage        Operation = "UpdatePage"
+	OpDeletePage        Operation = "DeletePage"
+)
+
+// minimumScope is the least privileged scope for each operation
+// on the notebooks of the signed-in user
+var minimumScope = map[Operation]Scope{
+	OpListNotebooks:     NotesRead,
+	OpListSectionGroups: NotesRead,
+	OpListSections:      NotesRead,
+	OpListPages:         NotesRead,
+	OpGetPage:           NotesRead,
+	OpGetPageContent:    NotesRead,
+	OpGetResource:       NotesRead,
+	OpCreatePage:        NotesCreate,
+	OpUpdatePage:        NotesReadWrite,
+	OpDeletePage:        NotesReadWrite,
+}
+
+// createReads are the operations that Notes.Create also allows on the
+// notebooks of the signed-in user, listing and page metadata
+var createReads = map[Operation]bool{
+	OpListNotebooks:     true,
+	OpListSectionGroups: true,
+	OpListSections:      true,
+	OpListPages:         true,
+	OpGetPage:           true,
+}
+
+// RequiredScope returns the minimum scope needed for op on the notebooks
+// of owner, notebooks of other users, groups and sites need the .All
+// scopes
+func RequiredScope(op Operation, owner string) Scope {
+	s, ok := minimumScope[op]
+	if !ok {
+		s = NotesRead
+	}
+
+	if isDefaultOwner(owner) {
+		return s
+	}
+
+	if s == NotesRead {
+		return NotesReadAll
+	}
+	return NotesReadWriteAll
+}
+
+// Allowed reports whether the granted scopes allow op on the notebooks
+// of owner, with RequiredScope or, for listing and page metadata of the
+// signed-in user, with Notes.Create
+func Allowed(granted []string, op Operation, owner string) bool {
+	if HasScope(granted, RequiredScope(op, owner)) {
+		return true
+	}
+	return createReads[op] && isDefaultOwner(owner) && HasScope(granted, NotesCreate)
+}
+
+// isDefaultOwner reports whether owner is the signed-in user
+func isDefaultOwner(owner string) bool {
+	owner = strings.Trim(owner, "/")
+	return owner == "" || strings.EqualFold(owner, DefaultOwner)
+}
+
+// InsufficientScopeError is returned when the token lacks the scope
+// needed for an operation
+type InsufficientScopeError struct {
+	// Operation that was attempted
+	Operation Operation
+
+	// Scope is the missing scope
+	Scope Scope
+
+	// Granted are the scopes of the token, nil if unknown
+	Granted []string
+
+	// Err is the error returned by the API, nil if the request was
+	// not sent because the scopes of the token are known
+	Err error
+}
+
+func (e *InsufficientScopeError) Error() string {
+	return fmt.Sprintf("onenote: %s requires scope %s", e.Operation, e.Scope)
+}
+
+func (e *InsufficientScopeError) Unwrap() error {
+	return e.Err
+}
+
+// checkScope returns an InsufficientScopeError if the granted scopes of
+// the Client are known and do not allow op
+func (c *Client) checkScope(op Operation) error {
+	granted := c.grantedScopes()
+	if granted == nil {
+		return nil
+	}
+
+	if Allowed(granted, op, c.Owner) {
+		return nil
+	}
+
+	return &InsufficientScopeError{
+		Operation: op,
+		Scope:     RequiredScope(op, c.Owner),
+		Granted:   granted,
+	}
+}
+
+// scopeCodes are the error codes of 403 responses for a token without
+// the scope of the request
+var scopeCodes = map[string]bool{
+	"40004":                       true,
+	"Authorization_RequestDenied": true,
+}
+
+// scopeError converts a 403 response into an InsufficientScopeError if
+// the granted scopes are known and do not allow op, or if unknown and the
+// error code is about scopes. Other 403 responses, such as for notebooks
+// that are not shared with the user, are returned as is.
+func (c *Client) scopeError(op Operation, err *Error) error {
+	granted := c.grantedScopes()
+	if granted != nil && Allowed(granted, op, c.Owner) {
+		return err
+	}
+	if granted == nil && !scopeCodes[err.Code] {
+		return err
+	}
+
+	return &InsufficientScopeError{
+		Operation: op,
+		Scope:     RequiredScope(op, c.Owner),
+		Granted:   granted,
+		Err:       err,
+	}
+}
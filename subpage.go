@@ -0,0 +1,105 @@
+package onenote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PageNode is a page with the subpages indented below it
+type PageNode struct {
+	Page     Page
+	Parent   *PageNode
+	Children []*PageNode
+}
+
+// PageTree returns the top level pages of a section with their subpages.
+// Pages are ordered by Order, and a page is a subpage of the closest page
+// above it with a lower Level. Use SectionPageLevels to list the pages
+// of a section with their Level and Order.
+func PageTree(pages []Page) []*PageNode {
+	sorted := make([]Page, len(pages))
+	copy(sorted, pages)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+
+	var roots []*PageNode
+	var stack []*PageNode
+	for _, page := range sorted {
+		node := &PageNode{Page: page}
+
+		// the parent is the closest page above with a lower level
+		for len(stack) > 0 && stack[len(stack)-1].Page.Level >= page.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			roots = append(roots, node)
+		} else {
+			node.Parent = stack[len(stack)-1]
+			node.Parent.Children = append(node.Parent.Children, node)
+		}
+		stack = append(stack, node)
+	}
+
+	return roots
+}
+
+// Depth returns the number of parents of the page, 0 for a top level page
+func (n *PageNode) Depth() int {
+	depth := 0
+	for p := n.Parent; p != nil; p = p.Parent {
+		depth++
+	}
+	return depth
+}
+
+// WalkPageTree calls fn for each page in nodes and its subpages in order,
+// stopping at the first error
+func WalkPageTree(nodes []*PageNode, fn func(*PageNode) error) error {
+	for _, n := range nodes {
+		if err := fn(n); err != nil {
+			return err
+		}
+		if err := WalkPageTree(n.Children, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RenderPageTree writes one line per page with subpages indented by
+// indent below their parent, label returns the text for a page and is
+// the title if nil
+func RenderPageTree(w io.Writer, nodes []*PageNode, indent string, label func(*Page) string) error {
+	if label == nil {
+		label = func(p *Page) string { return p.Title }
+	}
+
+	return WalkPageTree(nodes, func(n *PageNode) error {
+		_, err := fmt.Fprintf(w, "%s%s\n", strings.Repeat(indent, n.Depth()), label(&n.Page))
+		return err
+	})
+}
+
+// SectionPageLevels returns the pages of a section with their Level and
+// Order, which Graph only returns for the pages of one section or one
+// page when asked with pagelevel=true. Pages ignores pagelevel, so list
+// each section to build the page trees of a notebook.
+func (c *Client) SectionPageLevels(ctx context.Context, sectionID string, query url.Values) ([]Page, error) {
+	return c.SectionPages(sectionID, pageLevelQuery(query)).All(ctx)
+}
+
+// pageLevelQuery returns a copy of query asking for Level and Order
+func pageLevelQuery(query url.Values) url.Values {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("pagelevel", "true")
+	return q
+}
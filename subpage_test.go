@@ -0,0 +1,116 @@
+package onenote_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+func TestPageTree(t *testing.T) {
+	page := func(title string, order, level int32) onenote.Page {
+		return onenote.Page{Title: title, Order: order, Level: level}
+	}
+
+	tests := []struct {
+		name  string
+		pages []onenote.Page
+		want  string
+	}{
+		{"empty", nil, ""},
+		{"flat", []onenote.Page{page("a", 0, 0), page("b", 1, 0)}, "a\nb\n"},
+		{
+			"nested",
+			[]onenote.Page{page("a", 0, 0), page("a1", 1, 1), page("a1x", 2, 2), page("a2", 3, 1), page("b", 4, 0)},
+			"a\n. a1\n. . a1x\n. a2\nb\n",
+		},
+		{
+			"by order",
+			[]onenote.Page{page("a2", 2, 1), page("b", 3, 0), page("a", 0, 0), page("a1", 1, 1)},
+			"a\n. a1\n. a2\nb\n",
+		},
+		{
+			"level skipped",
+			[]onenote.Page{page("a", 0, 0), page("a2", 1, 2), page("b1", 2, 1)},
+			"a\n. a2\n. b1\n",
+		},
+		{
+			"subpage first",
+			[]onenote.Page{page("x", 0, 1), page("a", 1, 0)},
+			"x\na\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := onenote.RenderPageTree(&b, onenote.PageTree(tt.pages), ". ", nil); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("RenderPageTree() =\n%s\nwant\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPageTreeParents(t *testing.T) {
+	roots := onenote.PageTree([]onenote.Page{
+		{Title: "a", Order: 0}, {Title: "a1", Order: 1, Level: 1}, {Title: "a1x", Order: 2, Level: 2},
+	})
+	if len(roots) != 1 {
+		t.Fatalf("PageTree() = %d roots, want 1", len(roots))
+	}
+
+	leaf := roots[0].Children[0].Children[0]
+	if leaf.Page.Title != "a1x" || leaf.Depth() != 2 || leaf.Parent.Parent != roots[0] {
+		t.Errorf("leaf = %q at depth %d", leaf.Page.Title, leaf.Depth())
+	}
+
+	var b strings.Builder
+	err := onenote.RenderPageTree(&b, roots, "\t", func(p *onenote.Page) string { return strings.ToUpper(p.Title) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "A\n\tA1\n\t\tA1X\n"; b.String() != want {
+		t.Errorf("RenderPageTree() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestSectionPageLevels(t *testing.T) {
+	srv := onenotetest.NewServer()
+	defer srv.Close()
+	nb := srv.AddNotebook("Work")
+	sec := srv.AddSection(nb.Id, "Notes")
+	srv.AddPage(sec.Id, "Plan", "<p>plan</p>")
+	sub := srv.AddPage(sec.Id, "Details", "<p>details</p>")
+	srv.SetPageLevel(sub.Id, 1)
+	srv.AddPage(sec.Id, "Other", "<p>other</p>")
+
+	client := srv.Client()
+	ctx := context.Background()
+
+	pages, err := client.SectionPageLevels(ctx, sec.Id, url.Values{"$select": {"id,title,level,order"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	onenote.RenderPageTree(&b, onenote.PageTree(pages), "  ", nil)
+	if want := "Plan\n  Details\nOther\n"; b.String() != want {
+		t.Errorf("SectionPageLevels() tree =\n%s\nwant\n%s", b.String(), want)
+	}
+
+	// the pages of all sections have no levels, even when asked
+	all, err := client.Pages(url.Values{"pagelevel": {"true"}}).All(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range all {
+		if p.Level != 0 {
+			t.Errorf("Pages() page %q has level %d", p.Title, p.Level)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package onenote
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GraphAppID is the application id of Microsoft Graph, which may be
+// used as the audience of an access token
+const GraphAppID = "00000003-0000-0000-c000-000000000000"
+
+// ErrOpaqueToken is returned by ParseClaims for tokens that are not JWTs,
+// such as the access tokens issued for personal Microsoft accounts
+var ErrOpaqueToken = errors.New("onenote: access token is not a JWT")
+
+// Claims are the claims of an access token used for diagnostics.
+// The signature of the token is not verified.
+type Claims struct {
+	Audience          string   `json:"aud"`
+	Issuer            string   `json:"iss"`
+	TenantID          string   `json:"tid"`
+	ObjectID          string   `json:"oid"`
+	AppID             string   `json:"appid"`
+	Name              string   `json:"name"`
+	UPN               string   `json:"upn"`
+	UniqueName        string   `json:"unique_name"`
+	PreferredUsername string   `json:"preferred_username"`
+	Scope             string   `json:"scp"`
+	Roles             []string `json:"roles"`
+	IssuedAt          int64    `json:"iat"`
+	NotBefore         int64    `json:"nbf"`
+	ExpiresAt         int64    `json:"exp"`
+}
+
+// ParseClaims decodes the claims of a JWT access token without
+// verifying its signature
+func ParseClaims(token []byte) (*Claims, error) {
+	parts := strings.Split(strings.TrimSpace(string(token)), ".")
+	if len(parts) != 3 {
+		return nil, ErrOpaqueToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("onenote: cannot decode token claims: %w", err)
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("onenote: cannot unmarshal token claims: %w", err)
+	}
+
+	return &claims, nil
+}
+
+// Expiry returns the time the token expires
+func (c *Claims) Expiry() time.Time {
+	return time.Unix(c.ExpiresAt, 0)
+}
+
+// Expired reports whether the token has expired at the time now
+func (c *Claims) Expired(now time.Time) bool {
+	return c.ExpiresAt != 0 && !now.Before(c.Expiry())
+}
+
+// ExpiresWithin reports whether the token expires within d of the time
+// now, or has expired
+func (c *Claims) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return c.ExpiresAt != 0 && c.Expiry().Sub(now) < d
+}
+
+// Scopes returns the delegated scopes, or the application roles
+// of an app-only token
+func (c *Claims) Scopes() []string {
+	if c.Scope != "" {
+		return strings.Fields(c.Scope)
+	}
+	return c.Roles
+}
+
+// UserPrincipalName returns the signed-in user, if any
+func (c *Claims) UserPrincipalName() string {
+	switch {
+	case c.UPN != "":
+		return c.UPN
+	case c.PreferredUsername != "":
+		return c.PreferredUsername
+	}
+	return c.UniqueName
+}
+
+// IsGraphAudience reports whether the token was issued for Microsoft Graph
+func (c *Claims) IsGraphAudience() bool {
+	aud := strings.TrimSuffix(c.Audience, "/")
+	return aud == GraphAppID || strings.HasPrefix(aud, "https://graph.") ||
+		strings.HasPrefix(aud, "https://dod-graph.") ||
+		strings.HasPrefix(aud, "https://microsoftgraph.")
+}
+
+// TokenExpiredError is returned when the API rejects an expired token
+type TokenExpiredError struct {
+	// ExpiredAt is the expiry time from the token claims
+	ExpiredAt time.Time
+
+	// Claims of the rejected token
+	Claims *Claims
+
+	// Err is the error returned by the API
+	Err error
+}
+
+func (e *TokenExpiredError) Error() string {
+	return fmt.Sprintf("onenote: access token expired at %s",
+		e.ExpiredAt.Format(time.RFC3339))
+}
+
+func (e *TokenExpiredError) Unwrap() error {
+	return e.Err
+}
+
+// tokenClaims keeps the claims of the last token parsed by a Client,
+// so they are parsed once per token and not on every request
+type tokenClaims struct {
+	mu     sync.Mutex
+	parsed bool
+	token  string
+	claims *Claims
+	err    error
+}
+
+// parse returns the claims of token, parsed again only if it changed
+func (t *tokenClaims) parse(token []byte) (*Claims, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.parsed || t.token != string(token) {
+		t.parsed, t.token = true, string(token)
+		t.claims, t.err = ParseClaims(token)
+	}
+	return t.claims, t.err
+}
+
+// grantedScopes returns the scopes of the Client, or the scopes from the
+// token claims if not set, nil if unknown
+func (c *Client) grantedScopes() []string {
+	if c.Scopes != nil {
+		return c.Scopes
+	}
+
+	claims, err := c.parsedClaims.parse(c.Token)
+	if err != nil {
+		return nil
+	}
+
+	scopes := claims.Scopes()
+	if len(scopes) == 0 {
+		return nil
+	}
+	return scopes
+}
+
+// unauthorizedError converts a 401 response into a TokenExpiredError
+// if the token claims show it has expired
+func (c *Client) unauthorizedError(err *Error) error {
+	claims, parseErr := c.parsedClaims.parse(c.Token)
+	if parseErr != nil || !claims.Expired(time.Now()) {
+		return err
+	}
+
+	return &TokenExpiredError{
+		ExpiredAt: claims.Expiry(),
+		Claims:    claims,
+		Err:       err,
+	}
+}
@@ -0,0 +1,111 @@
+package onenote_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+// jwt returns an unsigned token with the claims in JSON
+func jwt(claims string) []byte {
+	enc := base64.RawURLEncoding
+	return []byte(enc.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		enc.EncodeToString([]byte(claims)) + ".")
+}
+
+func TestParseClaims(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      []byte
+		wantScopes []string
+		wantErr    error
+	}{
+		{"scopes", jwt(`{"scp":"Notes.Read User.Read","tid":"t"}`), []string{"Notes.Read", "User.Read"}, nil},
+		{"no scopes", jwt(`{"tid":"t"}`), nil, nil},
+		{"opaque", []byte("EwBwA8l6BAAU"), nil, onenote.ErrOpaqueToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := onenote.ParseClaims(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseClaims() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			got := claims.Scopes()
+			if len(got) != len(tt.wantScopes) {
+				t.Fatalf("Scopes() = %q, want %q", got, tt.wantScopes)
+			}
+			for i := range got {
+				if got[i] != tt.wantScopes[i] {
+					t.Errorf("Scopes() = %q, want %q", got, tt.wantScopes)
+				}
+			}
+		})
+	}
+}
+
+func TestClaimsExpiry(t *testing.T) {
+	expiry := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		token       []byte
+		now         time.Time
+		wantExpired bool
+		wantWithin  bool
+	}{
+		{"valid", jwt(`{"exp":1709287200}`), expiry.Add(-time.Hour), false, false},
+		{"expiring", jwt(`{"exp":1709287200}`), expiry.Add(-time.Minute), false, true},
+		{"at expiry", jwt(`{"exp":1709287200}`), expiry, true, true},
+		{"expired", jwt(`{"exp":1709287200}`), expiry.Add(time.Minute), true, true},
+		{"no expiry", jwt(`{"tid":"t"}`), expiry, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := onenote.ParseClaims(tt.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := claims.Expired(tt.now); got != tt.wantExpired {
+				t.Errorf("Expired() = %v, want %v", got, tt.wantExpired)
+			}
+			if got := claims.ExpiresWithin(tt.now, 5*time.Minute); got != tt.wantWithin {
+				t.Errorf("ExpiresWithin() = %v, want %v", got, tt.wantWithin)
+			}
+		})
+	}
+}
+
+func TestClientTokenChange(t *testing.T) {
+	srv := onenotetest.NewServer()
+	defer srv.Close()
+
+	exp := time.Now().Add(time.Hour).Unix()
+	client := srv.Client()
+	ctx := context.Background()
+
+	client.Token = jwt(`{"scp":"User.Read","exp":` + strconv.FormatInt(exp, 10) + `}`)
+	_, err := client.ListNotebooks(ctx, nil)
+	var scopeErr *onenote.InsufficientScopeError
+	if !errors.As(err, &scopeErr) {
+		t.Fatalf("ListNotebooks() error = %v, want InsufficientScopeError", err)
+	}
+	if srv.Requests() != 0 {
+		t.Errorf("Requests() = %d, want 0", srv.Requests())
+	}
+
+	// the claims of a new token are parsed again
+	client.Token = jwt(`{"scp":"Notes.Create","exp":` + strconv.FormatInt(exp, 10) + `}`)
+	if _, err := client.ListNotebooks(ctx, nil); err != nil {
+		t.Fatalf("ListNotebooks() error = %v", err)
+	}
+}
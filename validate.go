@@ -0,0 +1,356 @@
+package onenote
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Problem is a mistake in page HTML that OneNote drops, rewrites or
+// rejects, Line and Column start at 1 and are 0 for the whole request
+type Problem struct {
+	Line, Column int
+	Message      string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("%d:%d: %s", p.Line, p.Column, p.Message)
+}
+
+// ValidationError is returned by CreatePage and UpdatePage in Strict
+// mode for HTML with problems
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.String()
+	}
+	return "onenote: invalid page HTML: " + strings.Join(msgs, "; ")
+}
+
+// inputElements are the elements OneNote accepts in input HTML with
+// their attributes, in addition to globalAttributes
+var inputElements = map[string][]string{
+	"html":   {"xmlns"},
+	"head":   nil,
+	"title":  nil,
+	"meta":   {"name", "content", "http-equiv", "charset"},
+	"body":   {"data-absolute-enabled"},
+	"div":    nil,
+	"span":   nil,
+	"p":      nil,
+	"br":     nil,
+	"a":      {"href"},
+	"img":    {"src", "alt", "width", "height", "data-render-src", "data-render-method", "data-render-fallback"},
+	"object": {"data", "type", "data-attachment"},
+	"iframe": {"data-original-src", "width", "height"},
+	"h1":     nil,
+	"h2":     nil,
+	"h3":     nil,
+	"h4":     nil,
+	"h5":     nil,
+	"h6":     nil,
+	"ul":     nil,
+	"ol":     nil,
+	"li":     nil,
+	"table":  {"border"},
+	"tr":     nil,
+	"td":     nil,
+	"pre":    nil,
+	"b":      nil,
+	"strong": nil,
+	"i":      nil,
+	"em":     nil,
+	"u":      nil,
+	"strike": nil,
+	"del":    nil,
+	"sup":    nil,
+	"sub":    nil,
+	"cite":   nil,
+	"font":   {"face", "color", "size"},
+}
+
+// globalAttributes are accepted on all elements
+var globalAttributes = []string{"style", "lang", "data-id", "data-tag"}
+
+// inputStyles are the CSS properties OneNote accepts in input HTML
+var inputStyles = map[string]bool{
+	"position": true, "left": true, "top": true, "width": true, "height": true,
+	"color": true, "background-color": true,
+	"font-family": true, "font-size": true, "font-style": true, "font-weight": true,
+	"text-decoration": true, "text-align": true, "vertical-align": true,
+	"margin-top": true, "margin-bottom": true, "margin-left": true,
+}
+
+// Validate checks the HTML to create a page and the parts sent with it
+// against the OneNote input rules. It reports unsupported elements,
+// attributes and CSS properties, data-id values used more than once,
+// "name:" references to parts that are not given, parts that are not
+// used, and parts or requests over the size limits. Absolute positions
+// need data-absolute-enabled on the body, which then only holds div, img
+// and object elements.
+func Validate(html string, parts []Part) []Problem {
+	v := newValidator(parts)
+	v.check(html, true)
+	v.checkParts(len(html))
+	return v.problems
+}
+
+// ValidatePatch checks the content of the commands to update a page,
+// see Validate. The message of a problem starts with the index of the
+// command, and lines and columns are within the content of the command.
+// A data-id must be unique across all the commands.
+// The content for the title target is text and is not checked, and
+// absolute positions are not checked since the body is not known.
+func ValidatePatch(commands []PatchCommand) []Problem {
+	// one validator so data-id values are unique across the commands
+	v := newValidator(nil)
+	for i, cmd := range commands {
+		if strings.EqualFold(cmd.Target, "title") {
+			continue
+		}
+
+		start := len(v.problems)
+		v.command = fmt.Sprintf("command %d", i)
+		v.check(cmd.Content, false)
+		for j := start; j < len(v.problems); j++ {
+			v.problems[j].Message = v.command + ": " + v.problems[j].Message
+		}
+	}
+	return v.problems
+}
+
+// validator keeps the state of the checks
+type validator struct {
+	parts    map[string]int
+	used     map[string]bool
+	dataIDs  map[string]Problem
+	problems []Problem
+
+	// command is the patch command being checked, the message of the
+	// first use of a data-id
+	command string
+
+	// page is set for the HTML of a whole page, absolute if its body
+	// has data-absolute-enabled
+	page     bool
+	absolute bool
+
+	// lines are the offsets of the new lines in the HTML
+	lines []int
+}
+
+func newValidator(parts []Part) *validator {
+	v := &validator{
+		parts:   make(map[string]int),
+		used:    make(map[string]bool),
+		dataIDs: make(map[string]Problem),
+	}
+	for _, p := range parts {
+		v.parts[p.Name] = len(p.Data)
+	}
+	return v
+}
+
+// add reports a problem at line and column
+func (v *validator) add(line, column int, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+}
+
+// voidElements have no end tag
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// bodyElements are the elements allowed in a body with
+// data-absolute-enabled, other content must be in a div
+var bodyElements = []string{"div", "img", "object"}
+
+// check reports the problems in the elements of html, a whole page if
+// page is set
+func (v *validator) check(s string, page bool) {
+	v.page = page
+	v.lines = v.lines[:0]
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\n' {
+			v.lines = append(v.lines, i)
+		}
+	}
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	var open []string
+	offset := 0
+	for {
+		tt := z.Next()
+		line, column := v.position(offset)
+		offset += len(z.Raw())
+
+		switch tt {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				v.add(line, column, "malformed HTML: %v", err)
+			}
+			return
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if v.absolute && len(open) > 0 && open[len(open)-1] == "body" && !contains(bodyElements, tok.Data) {
+				v.add(line, column, "<%s> must be in a div in a body with data-absolute-enabled", tok.Data)
+			}
+			v.element(line, column, tok)
+			if tt == html.StartTagToken && !voidElements[tok.Data] {
+				open = append(open, tok.Data)
+			}
+
+		case html.EndTagToken:
+			// close the element and those left open inside it
+			name, _ := z.TagName()
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == string(name) {
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+}
+
+// position returns the line and column of the byte at offset
+func (v *validator) position(offset int) (line, column int) {
+	n := sort.SearchInts(v.lines, offset)
+	if n == 0 {
+		return 1, offset + 1
+	}
+	return n + 1, offset - v.lines[n-1]
+}
+
+// element reports the problems of an element and its attributes
+func (v *validator) element(line, column int, t html.Token) {
+	name := t.Data
+	allowed, ok := inputElements[name]
+	if !ok {
+		v.add(line, column, "unsupported element <%s>", name)
+		return
+	}
+
+	for _, a := range t.Attr {
+		key := a.Key
+		if a.Namespace != "" {
+			key = a.Namespace + ":" + key
+		}
+
+		if !contains(globalAttributes, key) && !contains(allowed, key) {
+			v.add(line, column, "unsupported attribute %s on <%s>", key, name)
+			continue
+		}
+
+		switch key {
+		case "style":
+			v.style(line, column, name, a.Val)
+
+		case "data-id":
+			if first, ok := v.dataIDs[a.Val]; !ok {
+				v.dataIDs[a.Val] = Problem{Line: line, Column: column, Message: v.command}
+			} else if first.Message != v.command {
+				v.add(line, column, "data-id %q is also used in %s at %d:%d", a.Val, first.Message, first.Line, first.Column)
+			} else {
+				v.add(line, column, "data-id %q is also used at %d:%d", a.Val, first.Line, first.Column)
+			}
+
+		case "src", "data", "data-render-src":
+			v.reference(line, column, name, key, a.Val)
+
+		case "data-absolute-enabled":
+			v.absolute = strings.EqualFold(a.Val, "true")
+		}
+	}
+}
+
+// style reports the unsupported properties of a style attribute, and
+// absolute positions in a page without data-absolute-enabled
+func (v *validator) style(line, column int, name, style string) {
+	for _, decl := range strings.Split(style, ";") {
+		prop, value, _ := strings.Cut(decl, ":")
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		if prop != "" && !inputStyles[prop] {
+			v.add(line, column, "unsupported CSS property %s on <%s>", prop, name)
+		}
+
+		if prop == "position" && strings.EqualFold(strings.TrimSpace(value), "absolute") && v.page && !v.absolute {
+			v.add(line, column, "position:absolute on <%s> without data-absolute-enabled on <body>", name)
+		}
+	}
+}
+
+// reference reports a "name:" reference to a part that is not given and
+// a URL OneNote cannot get
+func (v *validator) reference(line, column int, name, key, value string) {
+	if strings.HasPrefix(value, "name:") {
+		part := strings.TrimPrefix(value, "name:")
+		if _, found := v.parts[part]; !found {
+			v.add(line, column, "%s of <%s> refers to missing part %q", key, name, part)
+		}
+		v.used[part] = true
+		return
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		v.add(line, column, "%s of <%s> is not a URL: %v", key, name, err)
+		return
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "data":
+	default:
+		v.add(line, column, "%s of <%s> must be a name: part, http, https or data URL", key, name)
+	}
+}
+
+// checkParts reports unused parts and the size limits, see PageBuilder
+func (v *validator) checkParts(htmlSize int) {
+	names := make([]string, 0, len(v.parts))
+	for name := range v.parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	size := htmlSize
+	for _, name := range names {
+		if !v.used[name] {
+			v.add(0, 0, "part %q is not used", name)
+		}
+		if v.parts[name] > MaxPartSize {
+			v.add(0, 0, "part %q is larger than %d bytes", name, MaxPartSize)
+		}
+		size += v.parts[name]
+	}
+
+	if len(v.parts) > MaxParts {
+		v.add(0, 0, "more than %d parts", MaxParts)
+	}
+	if size > MaxRequestSize {
+		v.add(0, 0, "request is larger than %d bytes", MaxRequestSize)
+	}
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package onenote_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bnixon67/onenote"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		html  string
+		parts []onenote.Part
+		want  []string
+	}{
+		{
+			"valid",
+			`<html><head><title>T</title></head><body><p style="color:red">a</p></body></html>`,
+			nil,
+			nil,
+		},
+		{
+			"less than in text",
+			"<body>\n<p>a < b & c</p>\n</body>",
+			nil,
+			nil,
+		},
+		{
+			"unsupported",
+			"<body>\n<p onclick=\"x()\">a</p>\n<video></video>\n<p style=\"float:left\">b</p>\n</body>",
+			nil,
+			[]string{
+				"2:1: unsupported attribute onclick on <p>",
+				"3:1: unsupported element <video>",
+				"4:1: unsupported CSS property float on <p>",
+			},
+		},
+		{
+			"after errors",
+			"<body><p>a < b</p><blink>c</blink><p>d</p><marquee>e</marquee></body>",
+			nil,
+			[]string{
+				"1:19: unsupported element <blink>",
+				"1:43: unsupported element <marquee>",
+			},
+		},
+		{
+			"data-id",
+			`<body><p data-id="x">a</p><p data-id="x">b</p></body>`,
+			nil,
+			[]string{`1:27: data-id "x" is also used at 1:7`},
+		},
+		{
+			"parts",
+			`<body><img src="name:a" /><img src="ftp://host/b.png" /><object data="name:c" /></body>`,
+			[]onenote.Part{{Name: "a"}, {Name: "d"}},
+			[]string{
+				"1:27: src of <img> must be a name: part, http, https or data URL",
+				`1:57: data of <object> refers to missing part "c"`,
+				`part "d" is not used`,
+			},
+		},
+		{
+			"absolute",
+			`<body data-absolute-enabled="true">` +
+				`<div style="position:absolute;left:0px;top:0px"><p>a</p></div>` +
+				`<img src="https://host/a.png" style="position:absolute;left:0px;top:0px" /></body>`,
+			nil,
+			nil,
+		},
+		{
+			"absolute not enabled",
+			`<body><div style="position: Absolute;left:0px"><p>a</p></div></body>`,
+			nil,
+			[]string{"1:7: position:absolute on <div> without data-absolute-enabled on <body>"},
+		},
+		{
+			"absolute body content",
+			`<body data-absolute-enabled="true"><p>a</p><div><p>b</p><br /></div><br /></body>`,
+			nil,
+			[]string{
+				"1:36: <p> must be in a div in a body with data-absolute-enabled",
+				"1:69: <br> must be in a div in a body with data-absolute-enabled",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range onenote.Validate(tt.html, tt.parts) {
+				got = append(got, p.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateBuilder(t *testing.T) {
+	html, parts, err := onenote.NewPageBuilder().
+		Title("T").
+		Paragraph("a < b").
+		Image(strings.NewReader(png), "pic").
+		Outline(10, 20, 300).
+		ToDo("c", false).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := onenote.Validate(html, parts); len(problems) > 0 {
+		t.Errorf("Validate(%s) = %v, want none", html, problems)
+	}
+}
+
+func TestValidatePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []onenote.PatchCommand
+		want     []string
+	}{
+		{
+			"title",
+			[]onenote.PatchCommand{{Target: "title", Action: "replace", Content: "New <title> & stuff"}},
+			nil,
+		},
+		{
+			"body",
+			[]onenote.PatchCommand{
+				{Target: "body", Action: "append", Content: "<p>a < b</p>"},
+				{Target: "#x", Action: "replace", Content: "<p>\n<video></video></p>"},
+			},
+			[]string{"2:1: command 1: unsupported element <video>"},
+		},
+		{
+			"absolute",
+			[]onenote.PatchCommand{{Target: "body", Action: "append", Content: `<div style="position:absolute;left:0px;top:0px">a</div>`}},
+			nil,
+		},
+		{
+			"data-id",
+			[]onenote.PatchCommand{
+				{Target: "body", Action: "append", Content: `<p data-id="x">a</p>`},
+				{Target: "title", Action: "replace", Content: `<p data-id="x">`},
+				{Target: "body", Action: "append", Content: `<p data-id="y">b</p><p data-id="x">c</p>`},
+				{Target: "#z", Action: "replace", Content: `<p data-id="y">d</p>`},
+			},
+			[]string{
+				`1:21: command 2: data-id "x" is also used in command 0 at 1:1`,
+				`1:1: command 3: data-id "y" is also used in command 2 at 1:1`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range onenote.ValidatePatch(tt.commands) {
+				got = append(got, p.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidatePatch() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
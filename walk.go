@@ -0,0 +1,147 @@
+package onenote
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// SkipDir is returned by a WalkFunc to skip the children of a notebook,
+// section group or section. Returned for a page, it skips the remaining
+// pages of the section.
+var SkipDir = errors.New("onenote: skip this object")
+
+// SkipAll is returned by a WalkFunc to stop the walk without an error
+var SkipAll = errors.New("onenote: skip everything")
+
+// WalkFunc is called by Walk for each object. If the children of node
+// cannot be listed, it is called a second time for node with the error,
+// returning nil or SkipDir then continues with the next object. Any other
+// error stops the walk and is returned by Walk.
+type WalkFunc func(node *Resolved, err error) error
+
+// Walker walks the notebook hierarchy. The children of an object are
+// listed only after the WalkFunc returns nil for it, so SkipDir saves
+// the requests below. Objects are visited depth-first, so siblings are
+// listed one after the other, and only the section groups and sections
+// of one object are listed at once.
+type Walker struct {
+	Client *Client
+
+	// Workers is the number of concurrent list requests, DefaultWorkers
+	// if zero. At most two requests run at once, so values above 2 have
+	// the same effect as 2 and 1 lists one request at a time.
+	Workers int
+}
+
+// Walk calls fn for the object at the path root and every object below
+// it, depth-first in the order of the API: section groups before
+// sections, then pages. An empty root walks all notebooks.
+func (c *Client) Walk(ctx context.Context, root string, fn WalkFunc) error {
+	w := &Walker{Client: c}
+	return w.Walk(ctx, root, fn)
+}
+
+// Walk calls fn for the object at the path root and every object below
+// it, see Client.Walk
+func (w *Walker) Walk(ctx context.Context, root string, fn WalkFunc) error {
+	workers := w.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	ww := &walk{c: w.Client, fn: fn, sem: make(chan struct{}, workers)}
+
+	var err error
+	if strings.Trim(root, "/") == "" {
+		// the notebooks are the children of no object
+		err = ww.children(ctx, nil)
+	} else {
+		var node *Resolved
+		node, err = w.Client.Resolve(ctx, root)
+		if err != nil {
+			return err
+		}
+		err = ww.visit(ctx, node)
+	}
+
+	if err == SkipDir || err == SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walk is the state of one Walk
+type walk struct {
+	c   *Client
+	fn  WalkFunc
+	sem chan struct{}
+}
+
+// run calls the lists concurrently, each when a worker is free, and
+// returns when they are done
+func (ww *walk) run(lists ...func()) {
+	var wg sync.WaitGroup
+	for _, list := range lists {
+		ww.sem <- struct{}{}
+		wg.Add(1)
+		go func(list func()) {
+			defer wg.Done()
+			defer func() { <-ww.sem }()
+			list()
+		}(list)
+	}
+	wg.Wait()
+}
+
+// visit calls fn for node and then walks its children,
+// pages have no children and are not listed
+func (ww *walk) visit(ctx context.Context, node *Resolved) error {
+	if err := ww.fn(node, nil); err != nil {
+		return err
+	}
+	if node.Kind == KindPage {
+		return nil
+	}
+	return ww.children(ctx, node)
+}
+
+// children lists the children of node and walks them
+func (ww *walk) children(ctx context.Context, node *Resolved) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	children, err := ww.c.listChildren(ctx, node, ww.run)
+	if err != nil {
+		if node == nil {
+			return err
+		}
+		if err := ww.fn(node, err); err != nil && err != SkipDir {
+			return err
+		}
+		return nil
+	}
+
+	for i := range children {
+		child := &children[i]
+		child.Path = JoinPath(child.name())
+		if node != nil {
+			child.Path = node.Path + "/" + child.Path
+		}
+
+		err := ww.visit(ctx, child)
+		if err == SkipDir {
+			if child.Kind == KindPage {
+				return nil
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package onenote_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bnixon67/onenote"
+	"github.com/bnixon67/onenote/onenotetest"
+)
+
+// newWalkServer returns a Server with two notebooks:
+//
+//	Work/Projects/Launch/{Plan,Budget}
+//	Work/Notes/{Monday,Tuesday}
+//	Home/Shopping/List
+func newWalkServer(t *testing.T) *onenotetest.Server {
+	t.Helper()
+
+	srv := onenotetest.NewServer()
+	t.Cleanup(srv.Close)
+
+	work := srv.AddNotebook("Work")
+	launch := srv.AddSection(srv.AddSectionGroup(work.Id, "Projects").Id, "Launch")
+	srv.AddPage(launch.Id, "Plan", "<p>plan</p>")
+	srv.AddPage(launch.Id, "Budget", "<p>budget</p>")
+	notes := srv.AddSection(work.Id, "Notes")
+	srv.AddPage(notes.Id, "Monday", "<p>monday</p>")
+	srv.AddPage(notes.Id, "Tuesday", "<p>tuesday</p>")
+	srv.AddPage(srv.AddSection(srv.AddNotebook("Home").Id, "Shopping").Id, "List", "<p>list</p>")
+	return srv
+}
+
+func TestWalk(t *testing.T) {
+	errStop := errors.New("stop")
+
+	tests := []struct {
+		name         string
+		root         string
+		fn           func(node *onenote.Resolved) error
+		wantPaths    []string
+		wantRequests int
+		wantErr      error
+	}{
+		{
+			name: "all",
+			wantPaths: []string{
+				"Work", "Work/Projects", "Work/Projects/Launch",
+				"Work/Projects/Launch/Plan", "Work/Projects/Launch/Budget",
+				"Work/Notes", "Work/Notes/Monday", "Work/Notes/Tuesday",
+				"Home", "Home/Shopping", "Home/Shopping/List",
+			},
+			// the notebooks, groups and sections of Work, Projects and
+			// Home, and the pages of three sections
+			wantRequests: 10,
+		},
+		{
+			name: "skip sections",
+			fn: func(node *onenote.Resolved) error {
+				if node.Kind == onenote.KindSection {
+					return onenote.SkipDir
+				}
+				return nil
+			},
+			wantPaths: []string{
+				"Work", "Work/Projects", "Work/Projects/Launch", "Work/Notes",
+				"Home", "Home/Shopping",
+			},
+			wantRequests: 7,
+		},
+		{
+			name: "skip notebook",
+			fn: func(node *onenote.Resolved) error {
+				if node.Path == "Work" {
+					return onenote.SkipDir
+				}
+				return nil
+			},
+			wantPaths:    []string{"Work", "Home", "Home/Shopping", "Home/Shopping/List"},
+			wantRequests: 4,
+		},
+		{
+			name: "skip pages",
+			fn: func(node *onenote.Resolved) error {
+				if node.Kind == onenote.KindPage {
+					return onenote.SkipDir
+				}
+				return nil
+			},
+			wantPaths: []string{
+				"Work", "Work/Projects", "Work/Projects/Launch", "Work/Projects/Launch/Plan",
+				"Work/Notes", "Work/Notes/Monday",
+				"Home", "Home/Shopping", "Home/Shopping/List",
+			},
+			wantRequests: 10,
+		},
+		{
+			name: "skip all",
+			fn: func(node *onenote.Resolved) error {
+				if node.Path == "Work/Notes/Monday" {
+					return onenote.SkipAll
+				}
+				return nil
+			},
+			wantPaths: []string{
+				"Work", "Work/Projects", "Work/Projects/Launch",
+				"Work/Projects/Launch/Plan", "Work/Projects/Launch/Budget",
+				"Work/Notes", "Work/Notes/Monday",
+			},
+			wantRequests: 7,
+		},
+		{
+			name: "error",
+			fn: func(node *onenote.Resolved) error {
+				if node.Path == "Work/Projects" {
+					return errStop
+				}
+				return nil
+			},
+			wantPaths:    []string{"Work", "Work/Projects"},
+			wantRequests: 3,
+			wantErr:      errStop,
+		},
+		{
+			name: "root",
+			root: "work/projects",
+			wantPaths: []string{
+				"Work/Projects", "Work/Projects/Launch",
+				"Work/Projects/Launch/Plan", "Work/Projects/Launch/Budget",
+			},
+			// resolving the root lists the notebooks and the groups and
+			// sections of Work
+			wantRequests: 6,
+		},
+		{
+			name:         "page root",
+			root:         "Work/Notes/Monday",
+			wantPaths:    []string{"Work/Notes/Monday"},
+			wantRequests: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newWalkServer(t)
+
+			var paths []string
+			walker := onenote.Walker{Client: srv.Client(), Workers: 2}
+			err := walker.Walk(context.Background(), tt.root, func(node *onenote.Resolved, err error) error {
+				if err != nil {
+					return err
+				}
+				paths = append(paths, node.Path)
+				if tt.fn == nil {
+					return nil
+				}
+				return tt.fn(node)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Walk() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(paths, tt.wantPaths) {
+				t.Errorf("paths = %q, want %q", paths, tt.wantPaths)
+			}
+			if srv.Requests() != tt.wantRequests {
+				t.Errorf("Requests() = %d, want %d", srv.Requests(), tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestWalkListError(t *testing.T) {
+	srv := newWalkServer(t)
+	srv.InjectFault(onenotetest.Fault{Path: "/pages", Status: http.StatusForbidden})
+
+	var failed []string
+	err := srv.Client().Walk(context.Background(), "", func(node *onenote.Resolved, err error) error {
+		if err != nil {
+			failed = append(failed, node.Path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Work/Projects/Launch", "Work/Notes", "Home/Shopping"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("failed = %q, want %q", failed, want)
+	}
+}
+
+func TestWalkWorkers(t *testing.T) {
+	for _, workers := range []int{1, 2} {
+		srv := newWalkServer(t)
+
+		var mu sync.Mutex
+		var active, most int
+		client := srv.Client()
+		client.Middleware = []onenote.Middleware{func(next http.RoundTripper) http.RoundTripper {
+			return onenote.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				mu.Lock()
+				if active++; active > most {
+					most = active
+				}
+				mu.Unlock()
+				defer func() {
+					mu.Lock()
+					active--
+					mu.Unlock()
+				}()
+				return next.RoundTrip(req)
+			})
+		}}
+
+		walker := onenote.Walker{Client: client, Workers: workers}
+		if err := walker.Walk(context.Background(), "", func(*onenote.Resolved, error) error { return nil }); err != nil {
+			t.Fatal(err)
+		}
+		if most > workers {
+			t.Errorf("Workers %d: %d concurrent requests", workers, most)
+		}
+	}
+}
+
+func TestWalkConcurrent(t *testing.T) {
+	tests := []struct {
+		workers int
+		// requests sent while the section groups of Work are blocked,
+		// the notebooks and at most the sections of Work
+		want int
+	}{
+		{1, 2},
+		{2, 3},
+		{8, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.workers), func(t *testing.T) {
+			srv := newWalkServer(t)
+			block := make(chan struct{})
+			srv.InjectFault(onenotetest.Fault{Path: "/sectionGroups", Times: 1, Block: block})
+
+			walker := onenote.Walker{Client: srv.Client(), Workers: tt.workers}
+			done := make(chan error, 1)
+			go func() {
+				done <- walker.Walk(context.Background(), "", func(*onenote.Resolved, error) error { return nil })
+			}()
+
+			deadline := time.Now().Add(5 * time.Second)
+			for srv.Requests() < tt.want && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(50 * time.Millisecond)
+			if got := srv.Requests(); got != tt.want {
+				t.Errorf("Requests() = %d while blocked, want %d", got, tt.want)
+			}
+
+			close(block)
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}